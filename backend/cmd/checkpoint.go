@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"website-markdown/internal/scraper"
+)
+
+// Pages are spooled next to the state file so that a resumed crawl can
+// still write complete output for every format.
+func spoolPath(statePath string) string {
+	return statePath + ".pages.jsonl"
+}
+
+// pageSpool appends every page to the spool file before passing it on.
+type pageSpool struct {
+	file   *os.File
+	buffer *bufio.Writer
+	next   scraper.PageSink
+}
+
+func openPageSpool(statePath string, resume bool, next scraper.PageSink) (*pageSpool, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(spoolPath(statePath), flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to open page spool: %v", err)
+	}
+	return &pageSpool{file: file, buffer: bufio.NewWriter(file), next: next}, nil
+}
+
+func (p *pageSpool) WritePage(page *scraper.ScrapedPage) error {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	p.buffer.Write(data)
+	p.buffer.WriteByte('\n')
+	if err := p.buffer.Flush(); err != nil {
+		return fmt.Errorf("failed to write page spool: %v", err)
+	}
+	return p.next.WritePage(page)
+}
+
+func (p *pageSpool) Close() error {
+	return p.file.Close()
+}
+
+// readPageSpool returns the pages spooled by an earlier run. A line cut
+// short by a crash is ignored.
+func readPageSpool(statePath string) ([]*scraper.ScrapedPage, error) {
+	file, err := os.Open(spoolPath(statePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to open page spool: %v", err)
+	}
+	defer file.Close()
+
+	var pages []*scraper.ScrapedPage
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var page scraper.ScrapedPage
+		if err := json.Unmarshal(scanner.Bytes(), &page); err != nil {
+			continue
+		}
+		pages = append(pages, &page)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("❌ Failed to read page spool: %v", err)
+	}
+	return pages, nil
+}
+
+// removeCheckpoint deletes the state and spool files of a finished crawl.
+func removeCheckpoint(statePath string) {
+	os.Remove(statePath)
+	os.Remove(spoolPath(statePath))
+}
@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"website-markdown/internal/scraper"
@@ -21,10 +27,35 @@ var (
 	output         string
 	format         string
 	userAgent      string
+	urlsFile       string
+	warcOutput     string
+	checkpointFile string
+	resumeFile     string
+	order          string
+	preferPatterns []string
+	frontMatter    string
+	chunkSize      int
+	chunkOverlap   int
+	chunkUnit      string
+	maxTokens      int
+	duplicates     string
+	dupThreshold   float64
+	boilerplate    string
+	boilerThresh   float64
+	headingStyle   string
+	bulletMarker   string
+	codeFence      string
+	linkStyle      string
+	noGFM          bool
+	tableMode      string
+
+	// logOut receives human-readable progress. It is stderr when results
+	// are streamed to stdout, so they can be piped into other tools.
+	logOut io.Writer = os.Stdout
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "website-markdown [URL]",
+	Use:   "website-markdown [URL... | DIRECTORY | WARC]",
 	Short: "🔄 Convert websites to markdown recursively",
 	Long: `A powerful tool to convert websites to markdown format.
 Supports recursive scraping with configurable depth and delays.
@@ -32,8 +63,17 @@ Supports recursive scraping with configurable depth and delays.
 Examples:
   website-markdown https://example.com
   website-markdown https://example.com --depth 2 --output ./docs
-  website-markdown https://example.com --format json --external`,
-	Args: cobra.ExactArgs(1),
+  website-markdown https://example.com --format json --external
+  website-markdown https://example.com --format chunks --chunk-size 256 -o -
+  website-markdown https://example.com https://blog.example.com
+  website-markdown --urls-file sites.txt --format single
+  website-markdown https://docs.example.com --format llms
+  website-markdown ./public --format single
+  website-markdown https://example.com --warc crawl.warc.gz
+  website-markdown crawl.warc.gz --format json
+  website-markdown https://example.com --checkpoint crawl.state
+  website-markdown --resume crawl.state`,
+	Args: cobra.ArbitraryArgs,
 	RunE: runScraper,
 }
 
@@ -45,60 +85,648 @@ func init() {
 	rootCmd.Flags().IntVarP(&maxDepth, "depth", "d", 3, "Maximum depth for recursive scraping")
 	rootCmd.Flags().IntVar(&delay, "delay", 1000, "Delay between requests in milliseconds")
 	rootCmd.Flags().BoolVar(&followExternal, "external", false, "Follow external links")
-	rootCmd.Flags().StringVarP(&output, "output", "o", "", "Output directory (default: current directory)")
-	rootCmd.Flags().StringVarP(&format, "format", "f", "files", "Output format: files, json, single")
+	rootCmd.Flags().StringVarP(&output, "output", "o", "", "Output directory (default: current directory), or - for stdout with jsonl or chunks")
+	rootCmd.Flags().StringVarP(&format, "format", "f", "files", "Output format: files, json, jsonl, chunks, single, llms")
+	rootCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Stop the crawl once the pages add up to this many tokens (0 = no limit)")
+	rootCmd.Flags().StringVar(&duplicates, "duplicates", scraper.DUPLICATES_OFF, "Near-duplicate pages: off, group (mark them) or drop")
+	rootCmd.Flags().Float64Var(&dupThreshold, "duplicate-threshold", scraper.DEFAULT_DUPLICATE_THRESHOLD, "Similarity (0.5-1) from which pages count as near-duplicates")
+	rootCmd.Flags().StringVar(&boilerplate, "boilerplate", scraper.BOILERPLATE_KEEP, "Blocks repeated across pages: keep, strip, or chrome (strip, but save them once)")
+	rootCmd.Flags().Float64Var(&boilerThresh, "boilerplate-threshold", scraper.DEFAULT_BOILERPLATE_THRESHOLD, "Share of pages (0-1) a block must appear on to count as boilerplate")
+	addConverterFlags(rootCmd)
 	rootCmd.Flags().StringVar(&userAgent, "user-agent", "Website-Markdown-Converter/1.0", "User agent string")
+	rootCmd.Flags().StringVar(&urlsFile, "urls-file", "", "File with seed URLs to scrape, one per line")
+	rootCmd.Flags().StringVar(&warcOutput, "warc", "", "Record every HTTP request and response to this WARC file (.warc or .warc.gz)")
+	rootCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", "Save crawl progress to this state file so it can be resumed")
+	rootCmd.Flags().StringVar(&resumeFile, "resume", "", "Resume an interrupted crawl from its state file")
+	rootCmd.Flags().StringVar(&order, "order", "bfs", "Crawl order: bfs, dfs, best-first (with --prefer) or sitemap")
+	rootCmd.Flags().StringVar(&frontMatter, "front-matter", "", "Start each page file with yaml or toml front matter (files format)")
+	rootCmd.Flags().IntVar(&chunkSize, "chunk-size", scraper.DEFAULT_CHUNK_SIZE, "Maximum chunk size with the chunks format")
+	rootCmd.Flags().IntVar(&chunkOverlap, "chunk-overlap", scraper.DEFAULT_CHUNK_OVERLAP, "Text carried over between the chunks of a long section")
+	rootCmd.Flags().StringVar(&chunkUnit, "chunk-unit", scraper.CHUNK_UNIT_TOKENS, "Unit of --chunk-size and --chunk-overlap: tokens or chars")
+	rootCmd.Flags().StringArrayVar(&preferPatterns, "prefer", nil, "URL regexp=score to crawl first with best-first order (repeatable)")
 }
 
 func runScraper(cmd *cobra.Command, args []string) error {
-	url := args[0]
+	// Resuming takes the seeds and settings from the saved state
+	var state *scraper.CrawlState
+	if resumeFile != "" {
+		var err error
+		state, err = scraper.LoadCrawlState(resumeFile)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to load crawl state: %v", err)
+		}
+		checkpointFile = resumeFile
+	}
+
+	seeds := args
+	if state != nil {
+		seeds = state.SeedURLs()
+	}
+	if urlsFile != "" {
+		fileURLs, err := readURLsFile(urlsFile)
+		if err != nil {
+			return err
+		}
+		seeds = append(seeds, fileURLs...)
+	}
+	if len(seeds) == 0 {
+		return fmt.Errorf("❌ No URL given: pass one or more URLs or use --urls-file")
+	}
+
+	// A local directory (static site build) or a WARC archive is converted
+	// offline
+	var siteDir, warcInput string
+	for _, seed := range seeds {
+		dir, isDir := localDirectory(seed)
+		isWARC := isWARCFile(seed)
+		if !isDir && !isWARC {
+			continue
+		}
+		if len(seeds) > 1 {
+			return fmt.Errorf("❌ A directory or WARC archive must be the only input: %s", seed)
+		}
+		if checkpointFile != "" {
+			return fmt.Errorf("❌ Checkpoints are only supported when crawling URLs")
+		}
+
+		if isDir {
+			siteDir = dir
+			seeds = []string{scraper.DirectoryURL(dir)}
+		} else {
+			warcInput = seed
+			seeds = []string{archiveURL(seed)}
+		}
+	}
+
+	if frontMatter != "" {
+		if frontMatter != scraper.FRONT_MATTER_YAML && frontMatter != scraper.FRONT_MATTER_TOML {
+			return fmt.Errorf("❌ Invalid --front-matter %q: use yaml or toml", frontMatter)
+		}
+		if format != "files" {
+			return fmt.Errorf("❌ --front-matter is only supported with --format files")
+		}
+	}
+
+	if err := scraper.ValidateDuplicates(duplicates, dupThreshold); err != nil {
+		return fmt.Errorf("❌ Invalid duplicate settings: %v", err)
+	}
+
+	switch boilerplate {
+	case scraper.BOILERPLATE_KEEP, scraper.BOILERPLATE_STRIP, scraper.BOILERPLATE_CHROME:
+	default:
+		return fmt.Errorf("❌ Invalid --boilerplate %q: use keep, strip or chrome", boilerplate)
+	}
+	if boilerThresh <= 0 || boilerThresh > 1 {
+		return fmt.Errorf("❌ --boilerplate-threshold must be between 0 and 1")
+	}
+	if boilerplate == scraper.BOILERPLATE_CHROME && output == "-" {
+		return fmt.Errorf("❌ --boilerplate chrome writes a file, so it can't be used with -o -")
+	}
+
+	converter, err := converterOptions()
+	if err != nil {
+		return err
+	}
+
+	var chunking *scraper.ChunkOptions
+	if format == "chunks" {
+		chunking = &scraper.ChunkOptions{Size: chunkSize, Unit: chunkUnit}
+		if !cmd.Flags().Changed("chunk-size") {
+			chunking.Size = 0 // the default for the unit
+		}
+		if cmd.Flags().Changed("chunk-overlap") {
+			chunking.Overlap = &chunkOverlap // else the default for the unit and size
+		}
+		if err := chunking.Validate(); err != nil {
+			return fmt.Errorf("❌ Invalid chunk options: %v", err)
+		}
+	}
 
-	fmt.Printf("🚀 Starting website to markdown conversion\n")
-	fmt.Printf("📍 URL: %s\n", url)
-	fmt.Printf("📊 Max Depth: %d\n", maxDepth)
-	fmt.Printf("⏱️  Delay: %dms\n", delay)
-	fmt.Printf("🌐 Follow External: %t\n", followExternal)
+	if output == "-" {
+		if format != "jsonl" && format != "chunks" {
+			return fmt.Errorf("❌ Writing to stdout (-o -) is only supported with --format jsonl or chunks")
+		}
+		logOut = os.Stderr
+	}
 
 	config := &scraper.ScrapingConfig{
 		MaxDepth:       maxDepth,
 		Delay:          time.Duration(delay) * time.Millisecond,
 		FollowExternal: followExternal,
 		UserAgent:      userAgent,
+		MaxTokens:      maxTokens,
+
+		Duplicates:         duplicates,
+		DuplicateThreshold: dupThreshold,
+
+		Converter: converter,
+	}
+	if state != nil {
+		config = &state.Config
+	}
+
+	fmt.Fprintf(logOut, "🚀 Starting website to markdown conversion\n")
+	if siteDir != "" {
+		fmt.Fprintf(logOut, "📂 Directory: %s\n", siteDir)
+	} else if warcInput != "" {
+		fmt.Fprintf(logOut, "🗄️  WARC archive: %s\n", warcInput)
+	} else {
+		for _, seed := range seeds {
+			fmt.Fprintf(logOut, "📍 URL: %s\n", seed)
+		}
+	}
+	fmt.Fprintf(logOut, "📊 Max Depth: %d\n", config.MaxDepth)
+	fmt.Fprintf(logOut, "⏱️  Delay: %dms\n", config.Delay.Milliseconds())
+	fmt.Fprintf(logOut, "🌐 Follow External: %t\n", config.FollowExternal)
+	if config.MaxTokens > 0 {
+		fmt.Fprintf(logOut, "🪙 Token budget: %d\n", config.MaxTokens)
+	}
+	if state != nil {
+		fmt.Fprintf(logOut, "⏯️  Resuming from: %s (settings from the saved state)\n", resumeFile)
+	}
+
+	var warc *scraper.WARCWriter
+	if warcOutput != "" {
+		var err error
+		warc, err = scraper.CreateWARC(warcOutput)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to create WARC file: %v", err)
+		}
+		defer func() {
+			if err := warc.Close(); err != nil {
+				fmt.Fprintf(logOut, "⚠️  Failed to write WARC file: %v\n", err)
+			} else {
+				fmt.Fprintf(logOut, "🗄️  HTTP traffic recorded to: %s\n", warcOutput)
+			}
+		}()
+	}
+
+	if len(preferPatterns) > 0 && !cmd.Flags().Changed("order") {
+		order = "best-first"
+	}
+	if state != nil {
+		// The saved order is kept; a different one would reorder the queue
+		if cmd.Flags().Changed("order") && !sameOrder(order, config.Order) {
+			return fmt.Errorf("❌ The crawl was started with --order %s, resume it without --order", orderName(config.Order))
+		}
+		if cmd.Flags().Changed("prefer") && strings.Join(preferPatterns, "\n") != strings.Join(config.Prefer, "\n") {
+			return fmt.Errorf("❌ The crawl was started with other --prefer patterns, resume it without --prefer")
+		}
+		order, preferPatterns = config.Order, config.Prefer
+	}
+	ordering, err := scraper.ParseOrdering(order, preferPatterns)
+	if err != nil {
+		return fmt.Errorf("❌ Invalid crawl order: %v", err)
+	}
+
+	config.Log = logOut
+	config.Ordering = ordering
+	config.Order = order
+	config.Prefer = preferPatterns
+	config.Fetcher = localFetcher(config.UserAgent, warc)
+	if checkpointFile != "" {
+		config.Checkpoint = func(state *scraper.CrawlState) error {
+			return scraper.SaveCrawlState(checkpointFile, state)
+		}
 	}
 
+	// Ctrl-C stops the crawl; with a checkpoint it can be resumed later
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	s := scraper.NewScraper(config)
-	pages, err := s.ScrapeWebsite(url)
+	totals := &sizeTotals{}
+	scrape := func(sink scraper.PageSink) error {
+		sink = totals.counting(sink)
+		defer func() {
+			totals.print()
+			printDuplicateClusters(s.DuplicateClusters())
+		}()
+
+		switch {
+		case siteDir != "":
+			return s.ScrapeDirectory(ctx, siteDir, sink)
+		case warcInput != "":
+			file, err := os.Open(warcInput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			return s.ScrapeWARC(ctx, file, sink)
+		case checkpointFile != "":
+			return scrapeWithCheckpoint(ctx, s, state, seeds, sink)
+		default:
+			return s.ScrapeTo(ctx, sink, seeds...)
+		}
+	}
+
+	// JSON Lines are written page by page while the crawl runs, unless
+	// boilerplate has to be found across all pages first
+	streaming := format == "jsonl" || format == "chunks"
+	if streaming && boilerplate == scraper.BOILERPLATE_KEEP {
+		stream, err := newJSONLWriter(seeds[0], chunking)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+
+		if err := scrape(stream); err != nil {
+			return scrapeError(err)
+		}
+		return stream.Finish()
+	}
+
+	var pages []*scraper.ScrapedPage
+	err = scrape(scraper.PageSinkFunc(func(page *scraper.ScrapedPage) error {
+		pages = append(pages, page)
+		return nil
+	}))
 	if err != nil {
-		return fmt.Errorf("❌ Scraping failed: %v", err)
+		return scrapeError(err)
 	}
 
 	if len(pages) == 0 {
-		fmt.Println("⚠️  No pages were scraped")
+		fmt.Fprintln(logOut, "⚠️  No pages were scraped")
+		return nil
+	}
+
+	var chrome map[string]*scraper.Boilerplate
+	if boilerplate != scraper.BOILERPLATE_KEEP {
+		chrome = removeBoilerplate(pages)
+	}
+
+	if streaming {
+		stream, err := newJSONLWriter(seeds[0], chunking)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+
+		for _, page := range pages {
+			if err := stream.WritePage(page); err != nil {
+				return fmt.Errorf("❌ %v", err)
+			}
+		}
+		if err := saveSiteChrome(chrome, len(scraper.GroupBySeed(pages)) > 1); err != nil {
+			return err
+		}
+		return stream.Finish()
+	}
+
+	return saveOutput(pages, seeds, chrome)
+}
+
+// removeBoilerplate strips the blocks repeated across each seed's pages and
+// returns them by seed.
+func removeBoilerplate(pages []*scraper.ScrapedPage) map[string]*scraper.Boilerplate {
+	chrome := make(map[string]*scraper.Boilerplate)
+	for _, group := range scraper.GroupBySeed(pages) {
+		before := 0
+		for _, page := range group.Pages {
+			before += page.Tokens
+		}
+
+		found := scraper.RemoveBoilerplate(group.Pages, boilerThresh, nil)
+		if found == nil {
+			continue
+		}
+		chrome[group.Seed] = found
+
+		after := 0
+		for _, page := range group.Pages {
+			after += page.Tokens
+		}
+		fmt.Fprintf(logOut, "✂️  Removed %d boilerplate blocks from %d pages of %s (~%d → ~%d tokens)\n",
+			len(found.Blocks), found.Pages, group.Seed, before, after)
+	}
+	return chrome
+}
+
+// saveSiteChrome writes the boilerplate of each seed to site-chrome.md, with
+// --boilerplate chrome. With several seeds, each seed gets its own
+// subdirectory, like the files format.
+func saveSiteChrome(chrome map[string]*scraper.Boilerplate, multipleSeeds bool) error {
+	if boilerplate != scraper.BOILERPLATE_CHROME {
 		return nil
 	}
+	for seed, found := range chrome {
+		dir := output
+		if multipleSeeds {
+			dir = filepath.Join(output, seedDirName(seed))
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("❌ Failed to create output directory: %v", err)
+		}
+
+		filename := filepath.Join(dir, "site-chrome.md")
+		content := fmt.Sprintf("# Site Chrome: %s\n\n%s\n", seed, found.Markdown())
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			return fmt.Errorf("❌ Failed to write site chrome: %v", err)
+		}
+		fmt.Fprintf(logOut, "🧭 Site chrome saved to: %s\n", filename)
+	}
+	return nil
+}
+
+// sizeTotals adds up the size of the scraped pages for the summary.
+type sizeTotals struct {
+	pages int
+	size  scraper.TextSize
+}
+
+func (t *sizeTotals) counting(sink scraper.PageSink) scraper.PageSink {
+	return scraper.PageSinkFunc(func(page *scraper.ScrapedPage) error {
+		if page.Error == "" {
+			t.pages++
+			t.size = t.size.Add(page.TextSize)
+		}
+		return sink.WritePage(page)
+	})
+}
+
+func (t *sizeTotals) print() {
+	if t.pages == 0 {
+		return
+	}
+	fmt.Fprintf(logOut, "📏 Size: %d chars, %d words, ~%d tokens (%d tokens per page on average)\n",
+		t.size.Chars, t.size.Words, t.size.Tokens, t.size.Tokens/t.pages)
+}
+
+// printDuplicateClusters lists the near-duplicate pages found, grouped by
+// the page they duplicate.
+func printDuplicateClusters(clusters []scraper.DuplicateCluster) {
+	if len(clusters) == 0 {
+		return
+	}
+	fmt.Fprintf(logOut, "🧬 Near-duplicate clusters: %d\n", len(clusters))
+	for _, cluster := range clusters {
+		fmt.Fprintf(logOut, "   %s\n", cluster.URL)
+		for _, duplicate := range cluster.Duplicates {
+			fmt.Fprintf(logOut, "     ≈ %s (%.0f%% similar)\n", duplicate.URL, duplicate.Similarity*100)
+		}
+	}
+}
+
+// scrapeWithCheckpoint runs a crawl that saves its progress to
+// checkpointFile, resuming from state if it is set. Pages of the earlier run
+// are replayed from the spool first, so the output is complete.
+func scrapeWithCheckpoint(ctx context.Context, s *scraper.Scraper, state *scraper.CrawlState, seeds []string, sink scraper.PageSink) error {
+	spool, err := openPageSpool(checkpointFile, state != nil, sink)
+	if err != nil {
+		return err
+	}
+	defer spool.Close()
+
+	if state == nil {
+		err = s.ScrapeTo(ctx, spool, seeds...)
+	} else {
+		var spooled []*scraper.ScrapedPage
+		spooled, err = readPageSpool(checkpointFile)
+		if err != nil {
+			return err
+		}
+
+		for _, page := range spooled {
+			if err := sink.WritePage(page); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(logOut, "📦 Restored %d pages from the previous run\n", len(spooled))
+
+		// Pages written after the last checkpoint are done too, but are
+		// fetched again for their links
+		state.MarkCompleted(spooled...)
+		err = s.Resume(ctx, state, spool)
+	}
+	if err != nil {
+		return err
+	}
 
-	return saveOutput(pages, url)
+	removeCheckpoint(checkpointFile)
+	fmt.Fprintf(logOut, "🧹 Crawl finished, removed checkpoint %s\n", checkpointFile)
+	return nil
 }
 
-func saveOutput(pages []*scraper.ScrapedPage, baseURL string) error {
+func scrapeError(err error) error {
+	if checkpointFile != "" && errors.Is(err, context.Canceled) {
+		return fmt.Errorf("❌ Scraping interrupted, resume with: website-markdown --resume %s", checkpointFile)
+	}
+	return fmt.Errorf("❌ Scraping failed: %v", err)
+}
+
+// orderName is the --order value of a saved crawl order ("" is bfs).
+func orderName(order string) string {
+	if order == "" {
+		return "bfs"
+	}
+	return strings.ToLower(order)
+}
+
+func sameOrder(a, b string) bool {
+	return orderName(a) == orderName(b)
+}
+
+// localDirectory reports whether input is a local directory, given as a
+// path or a file:// URL, and returns its path.
+func localDirectory(input string) (string, bool) {
+	path := input
+	if strings.HasPrefix(input, "file://") {
+		parsedURL, err := url.Parse(input)
+		if err != nil {
+			return "", false
+		}
+		path = parsedURL.Path
+	} else if strings.Contains(input, "://") {
+		return "", false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	return path, true
+}
+
+// isWARCFile reports whether input is an existing .warc or .warc.gz file.
+func isWARCFile(input string) bool {
+	lower := strings.ToLower(input)
+	if !strings.HasSuffix(lower, ".warc") && !strings.HasSuffix(lower, ".warc.gz") {
+		return false
+	}
+	info, err := os.Stat(input)
+	return err == nil && !info.IsDir()
+}
+
+// archiveURL names the output of a WARC conversion after the archive,
+// e.g. crawl.warc.gz -> file://crawl/
+func archiveURL(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".warc")
+	return "file://" + sanitizeFilename(name) + "/"
+}
+
+// localFetcher fetches http(s) URLs over the network and file:// URLs from
+// disk. Only the CLI reads local files; the API server never does. If warc
+// is set, HTTP traffic is recorded to it.
+func localFetcher(userAgent string, warc *scraper.WARCWriter) scraper.Fetcher {
+	httpFetcher := scraper.NewHTTPFetcher(userAgent)
+	if warc != nil {
+		httpFetcher.RecordTo(warc)
+	}
+	return scraper.SchemeFetcher{
+		"http":  httpFetcher,
+		"https": httpFetcher,
+		"file":  scraper.FileFetcher{},
+	}
+}
+
+// readURLsFile reads seed URLs from path, one per line. Blank lines and
+// lines starting with # are skipped.
+func readURLsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to open URLs file: %v", err)
+	}
+	defer file.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("❌ Failed to read URLs file: %v", err)
+	}
+	return urls, nil
+}
+
+func saveOutput(pages []*scraper.ScrapedPage, seeds []string, chrome map[string]*scraper.Boilerplate) error {
 	if output == "" {
 		output = "."
 	}
 
+	baseURL := seeds[0]
+	groups := scraper.GroupBySeed(pages)
+
+	// The single file has a section for the site chrome instead
+	if format != "single" {
+		if err := saveSiteChrome(chrome, len(groups) > 1); err != nil {
+			return err
+		}
+	}
+
 	switch format {
 	case "json":
-		return saveAsJSON(pages, baseURL)
+		return saveAsJSON(pages, groups, baseURL)
 	case "single":
-		return saveAsSingleFile(pages, baseURL)
+		return saveAsSingleFile(groups, baseURL, chrome)
+	case "llms":
+		return saveAsLLMsTxt(groups)
 	default:
-		return saveAsFiles(pages, baseURL)
+		return saveAsFiles(groups)
+	}
+}
+
+// jsonlWriter streams pages as JSON Lines, one object per line, to stdout
+// or to a file in the output directory. With chunking set, each line is a
+// chunk of a page instead.
+type jsonlWriter struct {
+	file     *os.File
+	buffer   *bufio.Writer
+	encoder  *json.Encoder
+	filename string
+	chunking *scraper.ChunkOptions
+	count    int
+}
+
+func newJSONLWriter(baseURL string, chunking *scraper.ChunkOptions) (*jsonlWriter, error) {
+	w := &jsonlWriter{file: os.Stdout, filename: "stdout", chunking: chunking}
+
+	extension := "jsonl"
+	if chunking != nil {
+		extension = "chunks.jsonl"
+	}
+
+	if output != "-" {
+		if output == "" {
+			output = "."
+		}
+		if err := os.MkdirAll(output, 0755); err != nil {
+			return nil, fmt.Errorf("❌ Failed to create output directory: %v", err)
+		}
+
+		w.filename = filepath.Join(output, generateFilename(baseURL, extension))
+		file, err := os.Create(w.filename)
+		if err != nil {
+			return nil, fmt.Errorf("❌ Failed to create JSONL file: %v", err)
+		}
+		w.file = file
+	}
+
+	w.buffer = bufio.NewWriter(w.file)
+	w.encoder = json.NewEncoder(w.buffer)
+	return w, nil
+}
+
+// WritePage encodes one page (or its chunks) and flushes it right away. A
+// write error (such as a closed pipe) stops the crawl.
+func (w *jsonlWriter) WritePage(page *scraper.ScrapedPage) error {
+	var records []interface{}
+	if w.chunking != nil {
+		chunks, err := scraper.ChunkPage(page, *w.chunking)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range chunks {
+			records = append(records, chunk)
+		}
+	} else {
+		records = append(records, page)
 	}
+
+	for _, record := range records {
+		if err := w.encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write JSONL output: %v", err)
+		}
+	}
+	if err := w.buffer.Flush(); err != nil {
+		return fmt.Errorf("failed to write JSONL output: %v", err)
+	}
+	w.count += len(records)
+	return nil
 }
 
-func saveAsJSON(pages []*scraper.ScrapedPage, baseURL string) error {
+func (w *jsonlWriter) Finish() error {
+	records := "pages"
+	if w.chunking != nil {
+		records = "chunks"
+	}
+	fmt.Fprintf(logOut, "💾 Streamed %d %s as JSON Lines to: %s\n", w.count, records, w.filename)
+	return nil
+}
+
+func (w *jsonlWriter) Close() error {
+	if w.file == os.Stdout {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// saveAsJSON writes a flat array of pages for a single seed, or an array of
+// {seed, pages} groups when several seeds were scraped.
+func saveAsJSON(pages []*scraper.ScrapedPage, groups []scraper.SeedGroup, baseURL string) error {
 	filename := filepath.Join(output, generateFilename(baseURL, "json"))
 
-	data, err := json.MarshalIndent(pages, "", "  ")
+	var value interface{} = pages
+	if len(groups) > 1 {
+		value = groups
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
 	if err != nil {
 		return fmt.Errorf("❌ Failed to marshal JSON: %v", err)
 	}
@@ -108,18 +736,45 @@ func saveAsJSON(pages []*scraper.ScrapedPage, baseURL string) error {
 		return fmt.Errorf("❌ Failed to write JSON file: %v", err)
 	}
 
-	fmt.Printf("💾 JSON output saved to: %s\n", filename)
+	fmt.Fprintf(logOut, "💾 JSON output saved to: %s\n", filename)
 	return nil
 }
 
-func saveAsSingleFile(pages []*scraper.ScrapedPage, baseURL string) error {
+func saveAsSingleFile(groups []scraper.SeedGroup, baseURL string, chrome map[string]*scraper.Boilerplate) error {
 	filename := filepath.Join(output, generateFilename(baseURL, "md"))
 
 	var content strings.Builder
-	content.WriteString(fmt.Sprintf("# Website Content: %s\n\n", baseURL))
+	if len(groups) > 1 {
+		content.WriteString(fmt.Sprintf("# Website Content: %d sites\n\n", len(groups)))
+	} else {
+		content.WriteString(fmt.Sprintf("# Website Content: %s\n\n", baseURL))
+	}
 	content.WriteString(fmt.Sprintf("*Scraped on %s*\n\n", time.Now().Format("2006-01-02 15:04:05")))
 	content.WriteString("---\n\n")
 
+	for _, group := range groups {
+		if len(groups) > 1 {
+			content.WriteString(fmt.Sprintf("# 🌐 Site: %s\n\n", group.Seed))
+		}
+		if found := chrome[group.Seed]; found != nil && boilerplate == scraper.BOILERPLATE_CHROME {
+			content.WriteString("## 🧭 Site Chrome\n\n")
+			content.WriteString(fmt.Sprintf("*Repeated across %d pages, shown once*\n\n", found.Pages))
+			content.WriteString(found.Markdown())
+			content.WriteString("\n\n---\n\n")
+		}
+		writePageSections(&content, group.Pages)
+	}
+
+	err := os.WriteFile(filename, []byte(content.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to write markdown file: %v", err)
+	}
+
+	fmt.Fprintf(logOut, "💾 Single markdown file saved to: %s\n", filename)
+	return nil
+}
+
+func writePageSections(content *strings.Builder, pages []*scraper.ScrapedPage) {
 	for i, page := range pages {
 		if page.Error != "" {
 			content.WriteString(fmt.Sprintf("## ❌ Error: %s\n\n", page.URL))
@@ -131,53 +786,106 @@ func saveAsSingleFile(pages []*scraper.ScrapedPage, baseURL string) error {
 		content.WriteString(fmt.Sprintf("**URL:** %s  \n", page.URL))
 		content.WriteString(fmt.Sprintf("**Depth:** %d\n\n", page.Depth))
 		content.WriteString("---\n\n")
-		content.WriteString(page.Markdown)
+		// Each page numbers its referenced links from 1
+		content.WriteString(scraper.InlineLinkReferences(page.Markdown))
 		content.WriteString("\n\n")
 
 		if i < len(pages)-1 {
 			content.WriteString("---\n\n")
 		}
 	}
+}
 
-	err := os.WriteFile(filename, []byte(content.String()), 0644)
-	if err != nil {
-		return fmt.Errorf("❌ Failed to write markdown file: %v", err)
+// saveAsLLMsTxt writes llms.txt and llms-full.txt. With several seeds, each
+// seed gets its own subdirectory.
+func saveAsLLMsTxt(groups []scraper.SeedGroup) error {
+	for _, group := range groups {
+		dir := output
+		if len(groups) > 1 {
+			dir = filepath.Join(output, seedDirName(group.Seed))
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("❌ Failed to create output directory: %v", err)
+		}
+
+		files := map[string]string{
+			scraper.LLMS_TXT:      scraper.LLMsTxt(group.Seed, group.Pages),
+			scraper.LLMS_FULL_TXT: scraper.LLMsFullTxt(group.Seed, group.Pages),
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				return fmt.Errorf("❌ Failed to write %s: %v", name, err)
+			}
+		}
+		fmt.Fprintf(logOut, "💾 llms.txt and llms-full.txt saved to: %s\n", dir)
+	}
+	return nil
+}
+
+// saveAsFiles writes one file per page. With several seeds, each seed gets
+// its own subdirectory.
+func saveAsFiles(groups []scraper.SeedGroup) error {
+	successCount := 0
+	errorCount := 0
+
+	for _, group := range groups {
+		dir := output
+		if len(groups) > 1 {
+			dir = filepath.Join(output, seedDirName(group.Seed))
+		}
+
+		saved, failed, err := savePageFiles(group.Pages, dir)
+		if err != nil {
+			return err
+		}
+		successCount += saved
+		errorCount += failed
+	}
+
+	fmt.Fprintf(logOut, "✅ Successfully saved %d files to: %s\n", successCount, output)
+	if errorCount > 0 {
+		fmt.Fprintf(logOut, "⚠️  %d pages had errors\n", errorCount)
 	}
 
-	fmt.Printf("💾 Single markdown file saved to: %s\n", filename)
 	return nil
 }
 
-func saveAsFiles(pages []*scraper.ScrapedPage, baseURL string) error {
+func savePageFiles(pages []*scraper.ScrapedPage, dir string) (successCount, errorCount int, err error) {
 	// Create output directory
-	if err := os.MkdirAll(output, 0755); err != nil {
-		return fmt.Errorf("❌ Failed to create output directory: %v", err)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, 0, fmt.Errorf("❌ Failed to create output directory: %v", err)
 	}
 
-	successCount := 0
-	errorCount := 0
-
 	for i, page := range pages {
 		if page.Error != "" {
 			errorCount++
-			fmt.Printf("⚠️  Error on %s: %s\n", page.URL, page.Error)
+			fmt.Fprintf(logOut, "⚠️  Error on %s: %s\n", page.URL, page.Error)
 			continue
 		}
 
 		filename := fmt.Sprintf("page-%03d-%s.md", i+1, sanitizeFilename(page.Title))
-		filepath := filepath.Join(output, filename)
+		filepath := filepath.Join(dir, filename)
 
 		var content strings.Builder
-		content.WriteString(fmt.Sprintf("# %s\n\n", page.Title))
-		content.WriteString(fmt.Sprintf("**URL:** %s  \n", page.URL))
-		content.WriteString(fmt.Sprintf("**Depth:** %d  \n", page.Depth))
-		content.WriteString(fmt.Sprintf("**Scraped:** %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
-		content.WriteString("---\n\n")
+		if frontMatter != "" {
+			header, err := scraper.FrontMatter(page, frontMatter)
+			if err != nil {
+				return successCount, errorCount, fmt.Errorf("❌ Failed to write front matter: %v", err)
+			}
+			content.WriteString(header + "\n")
+			content.WriteString(fmt.Sprintf("# %s\n\n", page.Title))
+		} else {
+			content.WriteString(fmt.Sprintf("# %s\n\n", page.Title))
+			content.WriteString(fmt.Sprintf("**URL:** %s  \n", page.URL))
+			content.WriteString(fmt.Sprintf("**Depth:** %d  \n", page.Depth))
+			content.WriteString(fmt.Sprintf("**Scraped:** %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+			content.WriteString("---\n\n")
+		}
 		content.WriteString(page.Markdown)
 
 		err := os.WriteFile(filepath, []byte(content.String()), 0644)
 		if err != nil {
-			fmt.Printf("⚠️  Failed to write %s: %v\n", filename, err)
+			fmt.Fprintf(logOut, "⚠️  Failed to write %s: %v\n", filename, err)
 			errorCount++
 			continue
 		}
@@ -185,12 +893,23 @@ func saveAsFiles(pages []*scraper.ScrapedPage, baseURL string) error {
 		successCount++
 	}
 
-	fmt.Printf("✅ Successfully saved %d files to: %s\n", successCount, output)
-	if errorCount > 0 {
-		fmt.Printf("⚠️  %d pages had errors\n", errorCount)
+	return successCount, errorCount, nil
+}
+
+// seedDirName turns a seed URL into a directory name, e.g.
+// https://www.example.com/docs -> example-com-docs
+func seedDirName(seed string) string {
+	parsedURL, err := url.Parse(seed)
+	if err != nil {
+		return sanitizeFilename(seed)
 	}
 
-	return nil
+	name := strings.TrimPrefix(parsedURL.Hostname(), "www.")
+	if path := strings.Trim(parsedURL.Path, "/"); path != "" {
+		name += "-" + path
+	}
+	name = strings.ReplaceAll(name, ".", "-")
+	return sanitizeFilename(name)
 }
 
 func generateFilename(websiteURL, extension string) string {
@@ -242,3 +961,31 @@ func sanitizeFilename(title string) string {
 
 	return title
 }
+
+// addConverterFlags adds the Markdown style flags shared by the crawl and
+// convert commands.
+func addConverterFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&headingStyle, "heading-style", scraper.HEADING_ATX, "Markdown headings: atx (# Heading) or setext (underlined)")
+	flags.StringVar(&bulletMarker, "bullet", "-", "Bullet list marker: -, + or *")
+	flags.StringVar(&codeFence, "fence", "```", "Code block fence: ``` or ~~~")
+	flags.StringVar(&linkStyle, "link-style", scraper.LINK_INLINED, "Links: inlined or referenced (URLs listed at the end of the page)")
+	flags.BoolVar(&noGFM, "no-gfm", false, "Plain CommonMark: no GitHub Flavored tables, strikethrough or task lists")
+	flags.StringVar(&tableMode, "tables", scraper.TABLES_HTML, "Tables that don't fit a pipe table: html (sanitized) or records (a list per row)")
+}
+
+// converterOptions returns the validated options of the converter flags.
+func converterOptions() (scraper.ConverterOptions, error) {
+	converter := scraper.ConverterOptions{
+		HeadingStyle:     headingStyle,
+		BulletListMarker: bulletMarker,
+		Fence:            codeFence,
+		LinkStyle:        linkStyle,
+		DisableGFM:       noGFM,
+		Tables:           tableMode,
+	}
+	if err := converter.Validate(); err != nil {
+		return converter, fmt.Errorf("❌ Invalid converter options: %v", err)
+	}
+	return converter, nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"website-markdown/internal/scraper"
+)
+
+// writeSite writes a static site of linked pages to a temporary directory.
+func writeSite(t *testing.T, links map[string][]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for path, targets := range links {
+		var body strings.Builder
+		fmt.Fprintf(&body, "<html><head><title>Page %s</title></head><body><h1>Page %s</h1>", path, path)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(&body, "<p>Paragraph %d of page %s, long enough to count as real content for the crawler.</p>", i, path)
+		}
+		for _, target := range targets {
+			fmt.Fprintf(&body, `<p><a href="%s">%s</a></p>`, target, target)
+		}
+		body.WriteString("</body></html>")
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(body.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// withScraperFlags restores the scraper flags and log output after the test.
+func withScraperFlags(t *testing.T) {
+	t.Helper()
+	savedOutput, savedFormat, savedDelay, savedLog := output, format, delay, logOut
+	t.Cleanup(func() { output, format, delay, logOut = savedOutput, savedFormat, savedDelay, savedLog })
+	delay = 0
+}
+
+func TestJSONLStreamsToStdout(t *testing.T) {
+	withScraperFlags(t)
+	dir := writeSite(t, map[string][]string{"index.html": {"about.html"}, "about.html": nil})
+
+	output, format = "-", "jsonl"
+	stdout, stderr := captureStdout(t, func() error { return runScraper(rootCmd, []string{dir}) })
+
+	var urls []string
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		var page scraper.ScrapedPage
+		if err := json.Unmarshal(scanner.Bytes(), &page); err != nil {
+			t.Fatalf("stdout line isn't a page: %v\n%s", err, scanner.Text())
+		}
+		urls = append(urls, page.URL)
+	}
+	if len(urls) != 2 {
+		t.Errorf("stdout has pages %v, want one line for each of the 2 pages", urls)
+	}
+	if !strings.Contains(stderr, "🚀 Starting") || !strings.Contains(stderr, "Streamed 2 pages as JSON Lines to: stdout") {
+		t.Errorf("stderr = %q, want the progress", stderr)
+	}
+}
+
+func TestJSONLWriterChunks(t *testing.T) {
+	withScraperFlags(t)
+	output = t.TempDir()
+
+	stream, err := newJSONLWriter("https://example.com/", &scraper.ChunkOptions{Size: 20, Unit: scraper.CHUNK_UNIT_CHARS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := &scraper.ScrapedPage{URL: "https://example.com/", Markdown: "# One\n\nThe first section.\n\n# Two\n\nThe second section."}
+	if err := stream.WritePage(page); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(stream.filename, ".chunks.jsonl") {
+		t.Errorf("filename = %s, want a .chunks.jsonl file", stream.filename)
+	}
+	data, err := os.ReadFile(stream.filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 2 || len(lines) != stream.count {
+		t.Fatalf("wrote %d lines (count %d), want a line per chunk:\n%s", len(lines), stream.count, data)
+	}
+	var chunk scraper.Chunk
+	if err := json.Unmarshal([]byte(lines[0]), &chunk); err != nil || chunk.URL != page.URL {
+		t.Errorf("first line = %s, %v, want a chunk of the page", lines[0], err)
+	}
+}
+
+func TestStdoutNeedsAStreamingFormat(t *testing.T) {
+	withScraperFlags(t)
+	dir := writeSite(t, map[string][]string{"index.html": nil})
+
+	output, format = "-", "json"
+	if err := runScraper(rootCmd, []string{dir}); err == nil || !strings.Contains(err.Error(), "only supported with --format jsonl or chunks") {
+		t.Errorf("runScraper() error = %v, want -o - refused for json", err)
+	}
+}
+
+func TestSavePageFilesWithFrontMatter(t *testing.T) {
+	withScraperFlags(t)
+	defer func(saved string) { frontMatter = saved }(frontMatter)
+	logOut = io.Discard
+	dir := t.TempDir()
+
+	frontMatter = scraper.FRONT_MATTER_YAML
+	page := &scraper.ScrapedPage{URL: "https://example.com/guide", Title: "Guide", Markdown: "The guide."}
+	if _, _, err := savePageFiles([]*scraper.ScrapedPage{page}, dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "page-001-Guide.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "---\ntitle: Guide\nurl: https://example.com/guide\n") || strings.Contains(string(data), "**URL:**") {
+		t.Errorf("page file =\n%s\nwant front matter instead of the URL line", data)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"website-markdown/internal/scraper"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertBaseURL string
+	convertFormat  string
+	convertOutput  string
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert [URL | FILE | -]",
+	Short: "📝 Convert a single page or raw HTML to markdown without crawling",
+	Long: `Convert one page to markdown using the same extraction and cleaning as a crawl.
+No links are followed.
+
+The input can be a URL, an HTML file, or "-" (or nothing) to read HTML from stdin.
+
+Examples:
+  website-markdown convert https://example.com/docs/intro
+  website-markdown convert page.html --url https://example.com/page
+  curl -s https://example.com | website-markdown convert - --format json
+  website-markdown convert page.html --heading-style setext --tables records`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConvert,
+}
+
+func init() {
+	convertCmd.Flags().StringVar(&convertBaseURL, "url", "", "URL of the page when converting HTML from a file or stdin")
+	convertCmd.Flags().StringVarP(&convertFormat, "format", "f", "markdown", "Output format: markdown, json")
+	convertCmd.Flags().StringVarP(&convertOutput, "output", "o", "-", "Output file (default: stdout)")
+	convertCmd.Flags().StringVar(&userAgent, "user-agent", "Website-Markdown-Converter/1.0", "User agent string")
+	addConverterFlags(convertCmd)
+	rootCmd.AddCommand(convertCmd)
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	input := "-"
+	if len(args) == 1 {
+		input = args[0]
+	}
+
+	if convertFormat != "markdown" && convertFormat != "json" {
+		return fmt.Errorf("❌ Unknown format %q: use markdown or json", convertFormat)
+	}
+	converter, err := converterOptions()
+	if err != nil {
+		return err
+	}
+
+	s := scraper.NewScraper(&scraper.ScrapingConfig{
+		UserAgent: userAgent,
+		Converter: converter,
+		// stdout is for the page
+		Log: os.Stderr,
+	})
+
+	var page *scraper.ScrapedPage
+	switch {
+	case strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://"):
+		page, err = s.ConvertURL(context.Background(), input)
+	case input == "-":
+		page, err = s.ConvertHTML(os.Stdin, convertBaseURL)
+	default:
+		file, openErr := os.Open(input)
+		if openErr != nil {
+			return fmt.Errorf("❌ Failed to open %s: %v", input, openErr)
+		}
+		defer file.Close()
+		page, err = s.ConvertHTML(file, convertBaseURL)
+	}
+	if err != nil {
+		return fmt.Errorf("❌ Conversion failed: %v", err)
+	}
+	for _, warning := range page.Warnings {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", warning)
+	}
+
+	var out io.Writer = os.Stdout
+	if convertOutput != "-" {
+		file, err := os.Create(convertOutput)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch convertFormat {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(page)
+	case "markdown":
+		_, err = fmt.Fprintln(out, page.Markdown)
+	}
+	if err != nil {
+		return fmt.Errorf("❌ Failed to write output: %v", err)
+	}
+
+	if convertOutput != "-" {
+		fmt.Fprintf(os.Stderr, "💾 Converted %s to: %s\n", input, convertOutput)
+	}
+	return nil
+}
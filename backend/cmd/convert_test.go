@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"website-markdown/internal/scraper"
+)
+
+const mergedTablePage = `<html><head><title>Sizes</title></head><body>
+<h1>Sizes</h1>
+<table><tr><th>Name</th><th>Size</th></tr><tr><td colspan="2">Small</td></tr></table>
+</body></html>`
+
+// captureStdout runs f and returns what it wrote to os.Stdout and os.Stderr.
+func captureStdout(t *testing.T, f func() error) (stdout, stderr string) {
+	t.Helper()
+	outRead, outWrite, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errRead, errWrite, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	savedOut, savedErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outWrite, errWrite
+	read := func(r *os.File, into *string, done chan<- struct{}) {
+		data, _ := io.ReadAll(r)
+		*into = string(data)
+		done <- struct{}{}
+	}
+	done := make(chan struct{})
+	go read(outRead, &stdout, done)
+	go read(errRead, &stderr, done)
+
+	runErr := f()
+	os.Stdout, os.Stderr = savedOut, savedErr
+	outWrite.Close()
+	errWrite.Close()
+	<-done
+	<-done
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+	return stdout, stderr
+}
+
+func TestConvertWritesOnlyThePageToStdout(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(input, []byte(mergedTablePage), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	page, err := scraper.ConvertHTML(strings.NewReader(mergedTablePage), "", &scraper.ScrapingConfig{Log: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Warnings) == 0 {
+		t.Fatal("the page converts without warnings, so the test proves nothing")
+	}
+
+	defer func(format string) { convertFormat = format }(convertFormat)
+
+	convertFormat = "markdown"
+	stdout, stderr := captureStdout(t, func() error { return runConvert(convertCmd, []string{input}) })
+	if stdout != page.Markdown+"\n" {
+		t.Errorf("markdown stdout =\n%q\nwant the page only:\n%q", stdout, page.Markdown+"\n")
+	}
+	if !strings.Contains(stderr, "⚠️  Table 1 has merged cells: kept as HTML") {
+		t.Errorf("stderr = %q, want the table warning", stderr)
+	}
+
+	convertFormat = "json"
+	stdout, _ = captureStdout(t, func() error { return runConvert(convertCmd, []string{input}) })
+	var decoded scraper.ScrapedPage
+	if err := json.Unmarshal([]byte(stdout), &decoded); err != nil {
+		t.Fatalf("json stdout isn't a page: %v\n%s", err, stdout)
+	}
+	if decoded.Markdown != page.Markdown || len(decoded.Warnings) != len(page.Warnings) {
+		t.Errorf("json page = %+v, want %+v", decoded, page)
+	}
+}
+
+func TestConvertRejectsUnknownFormats(t *testing.T) {
+	defer func(format string) { convertFormat = format }(convertFormat)
+
+	convertFormat = "yaml"
+	if err := runConvert(convertCmd, []string{"page.html"}); err == nil || !strings.Contains(err.Error(), "Unknown format") {
+		t.Errorf("runConvert() error = %v, want an unknown format error", err)
+	}
+}
@@ -0,0 +1,322 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DEFAULT_KEY_MAX_CONCURRENT_JOBS = 2
+	DEFAULT_KEY_PAGES_PER_DAY       = 1000
+	DEFAULT_KEY_MAX_DEPTH           = 10
+	DEFAULT_KEY_MAX_PAGES_PER_JOB   = 100
+
+	apiKeyContextKey = "apiKey"
+)
+
+// APIKey is a single API key together with its quotas.
+// Zero quota values fall back to the defaults above.
+type APIKey struct {
+	Key               string `json:"key"`
+	Name              string `json:"name"`
+	MaxConcurrentJobs int    `json:"maxConcurrentJobs"`
+	PagesPerDay       int    `json:"pagesPerDay"`
+	MaxDepth          int    `json:"maxDepth"`
+	MaxPagesPerJob    int    `json:"maxPagesPerJob"` // also the budget of jobs that don't set maxPages
+}
+
+type keyUsage struct {
+	activeJobs int
+	day        string
+	pagesToday int // scraped or reserved by running jobs
+}
+
+// KeyStore holds the configured API keys and tracks their usage.
+// A nil or empty KeyStore disables authentication.
+type KeyStore struct {
+	keys  map[string]*APIKey
+	usage map[string]*keyUsage
+	mutex sync.Mutex
+}
+
+// QuotaError is returned when a request exceeds one of the key's quotas.
+type QuotaError struct {
+	Status  int
+	Message string
+}
+
+func (e *QuotaError) Error() string {
+	return e.Message
+}
+
+// NewKeyStore checks keys and fills in the default quotas. Keys and names
+// must be unique: jobs belong to the name of the key that started them.
+func NewKeyStore(keys []*APIKey) (*KeyStore, error) {
+	store := &KeyStore{
+		keys:  make(map[string]*APIKey),
+		usage: make(map[string]*keyUsage),
+	}
+
+	// A default name must not take one given to another key
+	named := make(map[string]bool)
+	for _, key := range keys {
+		named[key.Name] = true
+	}
+	seen := make(map[string]bool)
+	for i, key := range keys {
+		key.Key = strings.TrimSpace(key.Key)
+		if key.Key == "" {
+			return nil, fmt.Errorf("❌ API key #%d is empty", i+1)
+		}
+		if _, exists := store.keys[key.Key]; exists {
+			return nil, fmt.Errorf("❌ API key #%d duplicates an earlier key (%s)", i+1, maskKey(key.Key))
+		}
+
+		if key.Name == "" {
+			key.Name = fmt.Sprintf("key-%d", i+1)
+			if named[key.Name] {
+				return nil, fmt.Errorf("❌ API key #%d has no name and its default name %s is taken: give it a name", i+1, key.Name)
+			}
+		} else if seen[key.Name] {
+			return nil, fmt.Errorf("❌ API key #%d has the same name as an earlier key (%s)", i+1, key.Name)
+		}
+		seen[key.Name] = true
+
+		if key.MaxConcurrentJobs <= 0 {
+			key.MaxConcurrentJobs = DEFAULT_KEY_MAX_CONCURRENT_JOBS
+		}
+		if key.PagesPerDay <= 0 {
+			key.PagesPerDay = DEFAULT_KEY_PAGES_PER_DAY
+		}
+		if key.MaxDepth <= 0 {
+			key.MaxDepth = DEFAULT_KEY_MAX_DEPTH
+		}
+		if key.MaxPagesPerJob <= 0 {
+			key.MaxPagesPerJob = DEFAULT_KEY_MAX_PAGES_PER_JOB
+		}
+
+		store.keys[key.Key] = key
+		store.usage[key.Key] = &keyUsage{}
+	}
+
+	return store, nil
+}
+
+// LoadAPIKeysFile reads a JSON array of API keys from path.
+func LoadAPIKeysFile(path string) (*KeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to read API keys file: %v", err)
+	}
+
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("❌ Failed to parse API keys file: %v", err)
+	}
+
+	return NewKeyStore(keys)
+}
+
+// LoadAPIKeys loads keys from the given file, falling back to the
+// WEBSITE_MARKDOWN_API_KEYS_FILE and WEBSITE_MARKDOWN_API_KEYS environment
+// variables. The latter is a comma-separated list of "key" or "name:key"
+// entries that get the default quotas. Returns nil when no keys are configured.
+func LoadAPIKeys(path string) (*KeyStore, error) {
+	if path == "" {
+		path = os.Getenv(ENV_API_KEYS_FILE)
+	}
+	if path != "" {
+		return LoadAPIKeysFile(path)
+	}
+
+	raw := strings.TrimSpace(os.Getenv(ENV_API_KEYS))
+	if raw == "" {
+		return nil, nil
+	}
+
+	var keys []*APIKey
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key := &APIKey{Key: entry}
+		if name, value, found := strings.Cut(entry, ":"); found {
+			key.Name = name
+			key.Key = value
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeyStore(keys)
+}
+
+func (ks *KeyStore) Enabled() bool {
+	return ks != nil && len(ks.keys) > 0
+}
+
+func (ks *KeyStore) Lookup(key string) *APIKey {
+	if !ks.Enabled() {
+		return nil
+	}
+	return ks.keys[key]
+}
+
+// LookupName returns the key with the given name, which is how jobs
+// remember the key that started them.
+func (ks *KeyStore) LookupName(name string) *APIKey {
+	if !ks.Enabled() {
+		return nil
+	}
+	for _, key := range ks.keys {
+		if key.Name == name {
+			return key
+		}
+	}
+	return nil
+}
+
+// Acquire reserves a job slot for key, and pages of its daily quota: the
+// key's MaxPagesPerJob if pages is 0, or less if not that many are left.
+// It returns the number of pages reserved, which the job must not exceed;
+// Release refunds the ones it didn't use.
+func (ks *KeyStore) Acquire(key *APIKey, depth, pages int) (int, error) {
+	if depth > key.MaxDepth {
+		return 0, &QuotaError{
+			Status:  http.StatusForbidden,
+			Message: fmt.Sprintf("❌ Depth %d exceeds the maximum of %d allowed for this API key", depth, key.MaxDepth),
+		}
+	}
+	if pages > key.MaxPagesPerJob {
+		return 0, &QuotaError{
+			Status:  http.StatusForbidden,
+			Message: fmt.Sprintf("❌ maxPages %d exceeds the maximum of %d allowed for this API key", pages, key.MaxPagesPerJob),
+		}
+	}
+	if pages <= 0 {
+		pages = key.MaxPagesPerJob
+	}
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	usage := ks.usageFor(key)
+	if usage.activeJobs >= key.MaxConcurrentJobs {
+		return 0, &QuotaError{
+			Status:  http.StatusTooManyRequests,
+			Message: fmt.Sprintf("❌ Too many concurrent jobs (limit: %d)", key.MaxConcurrentJobs),
+		}
+	}
+
+	remaining := key.PagesPerDay - usage.pagesToday
+	if remaining <= 0 {
+		return 0, &QuotaError{
+			Status:  http.StatusTooManyRequests,
+			Message: fmt.Sprintf("❌ Daily page quota of %d exhausted or reserved by running jobs", key.PagesPerDay),
+		}
+	}
+	pages = min(pages, remaining)
+
+	usage.activeJobs++
+	usage.pagesToday += pages
+	return pages, nil
+}
+
+// Release frees the job slot taken by Acquire and refunds the reserved pages
+// the job didn't scrape.
+func (ks *KeyStore) Release(key *APIKey, reserved, scraped int) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	usage := ks.usageFor(key)
+	if usage.activeJobs > 0 {
+		usage.activeJobs--
+	}
+	if unused := reserved - scraped; unused > 0 {
+		// A reservation from yesterday was already reset with the day
+		usage.pagesToday = max(usage.pagesToday-unused, 0)
+	}
+}
+
+// usageFor returns the usage counters for key, resetting them at the start
+// of a new (UTC) day. Must be called with the mutex held.
+func (ks *KeyStore) usageFor(key *APIKey) *keyUsage {
+	usage := ks.usage[key.Key]
+	today := time.Now().UTC().Format("2006-01-02")
+	if usage.day != today {
+		usage.day = today
+		usage.pagesToday = 0
+	}
+	return usage
+}
+
+// requireAPIKey rejects requests without a valid "Authorization: Bearer <key>"
+// header. It is a no-op when authentication is disabled.
+func (s *Server) requireAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.keys.Enabled() {
+			c.Next()
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || strings.TrimSpace(token) == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="website-markdown"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "❌ Missing API key: use the Authorization: Bearer <key> header",
+			})
+			return
+		}
+
+		key := s.keys.Lookup(strings.TrimSpace(token))
+		if key == nil {
+			c.Header("WWW-Authenticate", `Bearer realm="website-markdown", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "❌ Invalid API key",
+			})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// acquireQuota reserves a job slot and up to pages pages (0 = the key's
+// MaxPagesPerJob) for the request's API key, if any. It returns the page budget
+// (0 = unlimited) and a release function that must be called with the
+// number of scraped pages once the job finishes.
+func (s *Server) acquireQuota(c *gin.Context, depth, pages int) (int, func(scraped int), error) {
+	value, exists := c.Get(apiKeyContextKey)
+	if !exists {
+		return pages, func(int) {}, nil
+	}
+
+	key := value.(*APIKey)
+	reserved, err := s.keys.Acquire(key, depth, pages)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	fmt.Printf("🔑 Job started for API key %s (%d pages reserved)\n", key.Name, reserved)
+	return reserved, func(scraped int) { s.keys.Release(key, reserved, scraped) }, nil
+}
+
+// maskKey shows only the start of an API key, for error messages.
+func maskKey(key string) string {
+	if len(key) < 12 {
+		return "****"
+	}
+	return key[:4] + "****"
+}
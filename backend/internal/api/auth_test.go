@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestKeyStoreReservesPages(t *testing.T) {
+	store, err := NewKeyStore([]*APIKey{{Key: "s3cret-key-one", MaxConcurrentJobs: 3, PagesPerDay: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := store.Lookup("s3cret-key-one")
+
+	first, err := store.Acquire(key, 1, 60)
+	if err != nil || first != 60 {
+		t.Fatalf("Acquire(60) = %d, %v, want 60", first, err)
+	}
+	second, err := store.Acquire(key, 1, 0)
+	if err != nil || second != 40 {
+		t.Fatalf("Acquire(0) = %d, %v, want the 40 pages left", second, err)
+	}
+	if _, err := store.Acquire(key, 1, 0); err == nil {
+		t.Fatal("Acquire() with the whole quota reserved succeeded")
+	}
+
+	// The first job scraped 10 of its 60 pages
+	store.Release(key, first, 10)
+	third, err := store.Acquire(key, 1, 0)
+	if err != nil || third != 50 {
+		t.Fatalf("Acquire(0) after the refund = %d, %v, want 50", third, err)
+	}
+}
+
+func TestNewKeyStoreDuplicateKey(t *testing.T) {
+	_, err := NewKeyStore([]*APIKey{{Key: "s3cret-key-one"}, {Key: "s3cret-key-one"}})
+	if err == nil {
+		t.Fatal("NewKeyStore() with a duplicate key succeeded")
+	}
+	if strings.Contains(err.Error(), "s3cret-key-one") || !strings.Contains(err.Error(), "#2") {
+		t.Errorf("error %q should name the key by index without showing it", err)
+	}
+}
+
+func TestKeyStoreBoundsJobsWithoutMaxPages(t *testing.T) {
+	store, err := NewKeyStore([]*APIKey{{Key: "s3cret-key-one", MaxConcurrentJobs: 5, PagesPerDay: 1000, MaxPagesPerJob: 300}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := store.Lookup("s3cret-key-one")
+
+	// Unbounded jobs get the per-job budget, so others still fit
+	for i, want := range []int{300, 300, 300, 100} {
+		reserved, err := store.Acquire(key, 1, 0)
+		if err != nil || reserved != want {
+			t.Fatalf("Acquire(0) #%d = %d, %v, want %d", i+1, reserved, err, want)
+		}
+	}
+
+	var quotaErr *QuotaError
+	if _, err := store.Acquire(key, 1, 301); !errors.As(err, &quotaErr) || quotaErr.Status != http.StatusForbidden {
+		t.Errorf("Acquire(301) error = %v, want 403 over maxPagesPerJob", err)
+	}
+}
+
+func TestNewKeyStoreNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []*APIKey
+		wantErr string
+		names   []string
+	}{
+		{"defaults", []*APIKey{{Key: "a"}, {Key: "b", Name: "ci"}, {Key: "c"}}, "", []string{"key-1", "ci", "key-3"}},
+		{"same name", []*APIKey{{Key: "a", Name: "ci"}, {Key: "b", Name: "ci"}}, "#2 has the same name", nil},
+		{"default name taken by a later key", []*APIKey{{Key: "a"}, {Key: "b", Name: "key-1"}}, "#1 has no name", nil},
+		{"default name taken by an earlier key", []*APIKey{{Key: "a", Name: "key-2"}, {Key: "b"}}, "#2 has no name", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, err := NewKeyStore(test.keys)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("NewKeyStore() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, key := range test.keys {
+				if store.Lookup(key.Key).Name != test.names[i] {
+					t.Errorf("key #%d is named %q, want %q", i+1, store.Lookup(key.Key).Name, test.names[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	DEFAULT_PORT           = "8080"
+	DEFAULT_READ_TIMEOUT   = 30 * time.Second
+	DEFAULT_MAX_BODY_BYTES = 1 << 20 // 1 MiB
+	DEFAULT_DRAIN_TIMEOUT  = 30 * time.Second
+	DEFAULT_JOB_TTL        = 7 * 24 * time.Hour
+
+	// Extra time cancelled crawls get to return partial results on shutdown
+	SHUTDOWN_GRACE_PERIOD = 10 * time.Second
+
+	// Environment variables used to configure the server
+	ENV_PORT           = "PORT"
+	ENV_BIND_ADDRESS   = "WEBSITE_MARKDOWN_BIND"
+	ENV_CORS_ORIGINS   = "WEBSITE_MARKDOWN_CORS_ORIGINS"
+	ENV_TLS_CERT       = "WEBSITE_MARKDOWN_TLS_CERT"
+	ENV_TLS_KEY        = "WEBSITE_MARKDOWN_TLS_KEY"
+	ENV_READ_TIMEOUT   = "WEBSITE_MARKDOWN_READ_TIMEOUT"
+	ENV_WRITE_TIMEOUT  = "WEBSITE_MARKDOWN_WRITE_TIMEOUT"
+	ENV_MAX_BODY_BYTES = "WEBSITE_MARKDOWN_MAX_BODY_BYTES"
+	ENV_DRAIN_TIMEOUT  = "WEBSITE_MARKDOWN_DRAIN_TIMEOUT"
+	ENV_STORE_PATH     = "WEBSITE_MARKDOWN_STORE"
+	ENV_JOB_TTL        = "WEBSITE_MARKDOWN_JOB_TTL"
+	ENV_PUBLIC_URL     = "WEBSITE_MARKDOWN_PUBLIC_URL"
+	ENV_API_KEYS       = "WEBSITE_MARKDOWN_API_KEYS"
+	ENV_API_KEYS_FILE  = "WEBSITE_MARKDOWN_API_KEYS_FILE"
+	ENV_ALLOW_HOSTS    = "WEBSITE_MARKDOWN_ALLOW_HOSTS"
+)
+
+// DefaultAllowedOrigins are the Vite dev and preview ports of the frontend.
+var DefaultAllowedOrigins = []string{"http://localhost:5173", "http://localhost:4173"}
+
+// Duration is a time.Duration that reads as "30s" style strings or as a
+// number of seconds in config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// ServerConfig holds everything needed to run the API server.
+// Values are layered: defaults, then config file, then environment, then flags.
+type ServerConfig struct {
+	BindAddress    string   `json:"bindAddress"` // empty means all interfaces
+	Port           string   `json:"port"`
+	AllowedOrigins []string `json:"allowedOrigins"`
+	PublicURL      string   `json:"publicUrl"` // used in webhook result links, defaults to the request host
+
+	TLSCertFile string `json:"tlsCertFile"`
+	TLSKeyFile  string `json:"tlsKeyFile"`
+
+	ReadTimeout  Duration `json:"readTimeout"`
+	WriteTimeout Duration `json:"writeTimeout"` // 0 disables it, crawls can take minutes
+	MaxBodyBytes int64    `json:"maxBodyBytes"`
+	DrainTimeout Duration `json:"drainTimeout"` // how long running crawls may finish on shutdown
+
+	APIKeysFile string   `json:"apiKeysFile"`
+	AllowHosts  []string `json:"allowHosts"`
+
+	StorePath string   `json:"storePath"` // BoltDB file, empty keeps jobs in memory
+	JobTTL    Duration `json:"jobTTL"`    // how long finished jobs are kept, 0 keeps them forever
+}
+
+func DefaultServerConfig() *ServerConfig {
+	return &ServerConfig{
+		Port:           DEFAULT_PORT,
+		AllowedOrigins: append([]string(nil), DefaultAllowedOrigins...),
+		ReadTimeout:    Duration(DEFAULT_READ_TIMEOUT),
+		MaxBodyBytes:   DEFAULT_MAX_BODY_BYTES,
+		DrainTimeout:   Duration(DEFAULT_DRAIN_TIMEOUT),
+		JobTTL:         Duration(DEFAULT_JOB_TTL),
+	}
+}
+
+// LoadServerConfig builds the server config from defaults, the optional
+// config file (JSON or YAML) and the environment.
+func LoadServerConfig(path string) (*ServerConfig, error) {
+	config := DefaultServerConfig()
+
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (c *ServerConfig) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to read config file: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to parse config file: %v", err)
+		}
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("❌ Failed to parse config file: %v", err)
+	}
+	return nil
+}
+
+func (c *ServerConfig) loadEnv() error {
+	if value := os.Getenv(ENV_PORT); value != "" {
+		c.Port = value
+	}
+	if value, ok := os.LookupEnv(ENV_BIND_ADDRESS); ok {
+		c.BindAddress = value
+	}
+	if value := os.Getenv(ENV_CORS_ORIGINS); value != "" {
+		c.AllowedOrigins = splitList(value)
+	}
+	if value := os.Getenv(ENV_TLS_CERT); value != "" {
+		c.TLSCertFile = value
+	}
+	if value := os.Getenv(ENV_TLS_KEY); value != "" {
+		c.TLSKeyFile = value
+	}
+	if value := os.Getenv(ENV_READ_TIMEOUT); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("❌ Invalid %s: %v", ENV_READ_TIMEOUT, err)
+		}
+		c.ReadTimeout = Duration(parsed)
+	}
+	if value := os.Getenv(ENV_WRITE_TIMEOUT); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("❌ Invalid %s: %v", ENV_WRITE_TIMEOUT, err)
+		}
+		c.WriteTimeout = Duration(parsed)
+	}
+	if value := os.Getenv(ENV_MAX_BODY_BYTES); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("❌ Invalid %s: %v", ENV_MAX_BODY_BYTES, err)
+		}
+		c.MaxBodyBytes = parsed
+	}
+	if value := os.Getenv(ENV_DRAIN_TIMEOUT); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("❌ Invalid %s: %v", ENV_DRAIN_TIMEOUT, err)
+		}
+		c.DrainTimeout = Duration(parsed)
+	}
+	if value := os.Getenv(ENV_PUBLIC_URL); value != "" {
+		c.PublicURL = value
+	}
+	if value := os.Getenv(ENV_STORE_PATH); value != "" {
+		c.StorePath = value
+	}
+	if value := os.Getenv(ENV_JOB_TTL); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("❌ Invalid %s: %v", ENV_JOB_TTL, err)
+		}
+		c.JobTTL = Duration(parsed)
+	}
+	if value := os.Getenv(ENV_API_KEYS_FILE); value != "" {
+		c.APIKeysFile = value
+	}
+	if value := os.Getenv(ENV_ALLOW_HOSTS); value != "" {
+		c.AllowHosts = append(c.AllowHosts, splitList(value)...)
+	}
+	return nil
+}
+
+// Validate checks the config for obvious mistakes before the server starts.
+func (c *ServerConfig) Validate() error {
+	if c.Port == "" {
+		c.Port = DEFAULT_PORT
+	}
+	if _, err := strconv.Atoi(c.Port); err != nil {
+		return fmt.Errorf("❌ Invalid port: %s", c.Port)
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("❌ Both a TLS certificate and key are required to enable TLS")
+	}
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("❌ Invalid max body size: %d", c.MaxBodyBytes)
+	}
+	if c.JobTTL < 0 {
+		return fmt.Errorf("❌ Invalid job TTL: %v", time.Duration(c.JobTTL))
+	}
+	if c.DrainTimeout < 0 {
+		return fmt.Errorf("❌ Invalid drain timeout: %v", time.Duration(c.DrainTimeout))
+	}
+	return nil
+}
+
+// Address returns the host:port the server listens on.
+func (c *ServerConfig) Address() string {
+	return net.JoinHostPort(c.BindAddress, c.Port)
+}
+
+func (c *ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
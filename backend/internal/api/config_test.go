@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadServerConfigLayers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.yaml")
+	file := `port: "9000"
+bindAddress: 127.0.0.1
+allowedOrigins: [https://docs.example.com]
+readTimeout: 10
+drainTimeout: 1m
+`
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The environment overrides the file
+	t.Setenv(ENV_PORT, "9100")
+	t.Setenv(ENV_CORS_ORIGINS, "https://a.example.com, https://b.example.com")
+
+	config, err := LoadServerConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Address() != "127.0.0.1:9100" {
+		t.Errorf("Address() = %s, want the file's bind address and the environment's port", config.Address())
+	}
+	if got := strings.Join(config.AllowedOrigins, " "); got != "https://a.example.com https://b.example.com" {
+		t.Errorf("AllowedOrigins = %s, want the environment's", got)
+	}
+	if config.ReadTimeout != Duration(10*time.Second) || config.DrainTimeout != Duration(time.Minute) {
+		t.Errorf("timeouts = %v, %v, want 10s and 1m", time.Duration(config.ReadTimeout), time.Duration(config.DrainTimeout))
+	}
+	// Unset values keep their defaults
+	if config.MaxBodyBytes != DEFAULT_MAX_BODY_BYTES || config.JobTTL != Duration(DEFAULT_JOB_TTL) {
+		t.Errorf("MaxBodyBytes = %d, JobTTL = %v, want the defaults", config.MaxBodyBytes, time.Duration(config.JobTTL))
+	}
+}
+
+func TestLoadServerConfigInvalidEnv(t *testing.T) {
+	t.Setenv(ENV_READ_TIMEOUT, "soon")
+	if _, err := LoadServerConfig(""); err == nil || !strings.Contains(err.Error(), ENV_READ_TIMEOUT) {
+		t.Errorf("LoadServerConfig() error = %v, want the invalid variable named", err)
+	}
+}
+
+func TestServerConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*ServerConfig)
+		wantErr string
+	}{
+		{"defaults", func(*ServerConfig) {}, ""},
+		{"port", func(c *ServerConfig) { c.Port = "http" }, "Invalid port"},
+		{"certificate without key", func(c *ServerConfig) { c.TLSCertFile = "cert.pem" }, "TLS"},
+		{"negative body size", func(c *ServerConfig) { c.MaxBodyBytes = -1 }, "max body size"},
+		{"negative drain timeout", func(c *ServerConfig) { c.DrainTimeout = -1 }, "drain timeout"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultServerConfig()
+			test.change(config)
+			err := config.Validate()
+			if test.wantErr == "" && err != nil || test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestServerAllowedOrigins(t *testing.T) {
+	config := DefaultServerConfig()
+	config.AllowedOrigins = []string{"https://docs.example.com"}
+	server := NewServer(config, nil, nil)
+
+	for origin, allowed := range map[string]bool{"https://docs.example.com": true, "http://localhost:5173": false} {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("Origin", origin)
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, req)
+		if got := recorder.Header().Get("Access-Control-Allow-Origin") == origin; got != allowed {
+			t.Errorf("%s: allowed = %t, want %t", origin, got, allowed)
+		}
+	}
+}
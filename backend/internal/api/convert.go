@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"website-markdown/internal/scraper"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConvertRequest converts either raw HTML or a single URL, without crawling.
+// When both are set, URL is only used as the page's URL.
+type ConvertRequest struct {
+	HTML string `json:"html"`
+	URL  string `json:"url"`
+
+	// Optional Markdown style, as for /scrape
+	Converter scraper.ConverterOptions `json:"converter"`
+}
+
+type ConvertResponse struct {
+	Success bool                 `json:"success"`
+	Page    *scraper.ScrapedPage `json:"page,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+// convertPage handles POST /convert. The body is either a ConvertRequest
+// (JSON) or raw HTML with a text/html content type and an optional ?url=.
+// Add ?format=markdown to get the markdown itself instead of JSON.
+func (s *Server) convertPage(c *gin.Context) {
+	format := c.Query("format")
+	if format != "" && format != "json" && format != "markdown" {
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   fmt.Sprintf("❌ Unknown format %q: use json or markdown", format),
+		})
+		return
+	}
+
+	var req ConvertRequest
+	if strings.HasPrefix(c.ContentType(), "text/html") {
+		body := c.Request.Body
+		if s.config.MaxBodyBytes > 0 {
+			body = http.MaxBytesReader(c.Writer, body, s.config.MaxBodyBytes)
+		}
+		html, err := io.ReadAll(body)
+		if err != nil {
+			c.JSON(bindErrorStatus(err), ConvertResponse{
+				Success: false,
+				Error:   fmt.Sprintf("❌ Failed to read body: %v", err),
+			})
+			return
+		}
+		req.HTML = string(html)
+		req.URL = c.Query("url")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(bindErrorStatus(err), ConvertResponse{
+			Success: false,
+			Error:   fmt.Sprintf("❌ Invalid request: %v", err),
+		})
+		return
+	}
+
+	if req.HTML == "" && req.URL == "" {
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   "❌ Either html or url is required",
+		})
+		return
+	}
+
+	if err := req.Converter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   fmt.Sprintf("❌ Invalid converter: %v", err),
+		})
+		return
+	}
+
+	converter := scraper.NewScraper(s.scrapingConfig(&ScrapeRequest{URL: req.URL, Converter: req.Converter}, 1))
+
+	var page *scraper.ScrapedPage
+	var err error
+	if req.HTML != "" {
+		page, err = converter.ConvertHTML(strings.NewReader(req.HTML), req.URL)
+	} else {
+		var release func(pages int)
+		_, release, err = s.acquireQuota(c, 0, 1)
+		if err != nil {
+			c.JSON(quotaStatus(err), ConvertResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		fmt.Printf("🔄 API convert request: %s\n", req.URL)
+		page, err = converter.ConvertURL(jobContext(c), req.URL)
+		if err == nil {
+			release(1)
+		} else {
+			release(0)
+		}
+	}
+
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		var blockedErr *scraper.BlockedAddressError
+		if errors.As(err, &blockedErr) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, ConvertResponse{
+			Success: false,
+			Error:   fmt.Sprintf("❌ Conversion failed: %v", err),
+		})
+		return
+	}
+
+	if format == "markdown" {
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(page.Markdown))
+		return
+	}
+
+	c.JSON(http.StatusOK, ConvertResponse{
+		Success: true,
+		Page:    page,
+	})
+}
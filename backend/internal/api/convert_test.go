@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const convertTestPage = `<html><head><title>Guide</title></head><body><h1>Guide</h1>
+<p>The first paragraph of the guide explains what the tool does and who it is for.</p>
+<p>The second paragraph walks through installing it on the common platforms.</p>
+<p>The third paragraph covers the configuration file and its defaults.</p>
+</body></html>`
+
+func postConvert(server *Server, query, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/convert"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestConvertPageFormats(t *testing.T) {
+	server := NewServer(nil, nil, nil)
+
+	recorder := postConvert(server, "?format=markdown", "text/html", convertTestPage)
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "# Guide\n\nThe first paragraph") {
+		t.Errorf("?format=markdown = %d %q, want the Markdown", recorder.Code, recorder.Body.String())
+	}
+
+	body, _ := json.Marshal(ConvertRequest{HTML: convertTestPage})
+	recorder = postConvert(server, "?format=json", "application/json", string(body))
+	var response ConvertResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || !response.Success || response.Page.Title != "Guide" {
+		t.Errorf("?format=json = %d %q, want the page as JSON", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = postConvert(server, "?format=yaml", "text/html", convertTestPage)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "Unknown format") {
+		t.Errorf("?format=yaml = %d %q, want 400", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestConvertPageLimitsRawHTML(t *testing.T) {
+	config := DefaultServerConfig()
+	config.MaxBodyBytes = 64
+	server := NewServer(config, nil, nil)
+
+	recorder := postConvert(server, "", "text/html", convertTestPage)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized HTML = %d %q, want 413", recorder.Code, recorder.Body.String())
+	}
+}
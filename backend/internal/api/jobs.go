@@ -0,0 +1,620 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"website-markdown/internal/scraper"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	JOB_CLEANUP_INTERVAL = 10 * time.Minute
+	MAX_SEED_URLS        = 50 // per scrape request
+)
+
+// bindScrapeRequest parses and validates a ScrapeRequest, applying defaults.
+// It writes the error response itself and returns ok=false on failure.
+func (s *Server) bindScrapeRequest(c *gin.Context) (*ScrapeRequest, bool) {
+	var req ScrapeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(bindErrorStatus(err), ScrapeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("❌ Invalid request: %v", err),
+		})
+		return nil, false
+	}
+
+	// Validate URLs
+	seeds := req.Seeds()
+	if len(seeds) == 0 {
+		c.JSON(http.StatusBadRequest, ScrapeResponse{
+			Success: false,
+			Error:   "❌ URL is required",
+		})
+		return nil, false
+	}
+	if len(seeds) > MAX_SEED_URLS {
+		c.JSON(http.StatusBadRequest, ScrapeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("❌ Too many URLs: at most %d per request", MAX_SEED_URLS),
+		})
+		return nil, false
+	}
+
+	// Set defaults
+	if req.MaxDepth <= 0 {
+		req.MaxDepth = 3
+	}
+	if req.MaxDepth > 10 {
+		req.MaxDepth = 10 // Prevent abuse
+	}
+	if req.Delay <= 0 {
+		req.Delay = 1000
+	}
+	if req.Delay < 500 {
+		req.Delay = 500 // Minimum delay to be respectful
+	}
+
+	if req.CallbackURL != "" {
+		if err := validateCallbackURL(req.CallbackURL); err != nil {
+			c.JSON(http.StatusBadRequest, ScrapeResponse{
+				Success: false,
+				Error:   fmt.Sprintf("❌ Invalid callbackUrl: %v", err),
+			})
+			return nil, false
+		}
+	}
+
+	if req.DuplicateThreshold == 0 {
+		req.DuplicateThreshold = scraper.DEFAULT_DUPLICATE_THRESHOLD
+	}
+	if err := scraper.ValidateDuplicates(req.Duplicates, req.DuplicateThreshold); err != nil {
+		c.JSON(http.StatusBadRequest, ScrapeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("❌ Invalid duplicates: %v", err),
+		})
+		return nil, false
+	}
+
+	if err := req.Converter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, ScrapeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("❌ Invalid converter: %v", err),
+		})
+		return nil, false
+	}
+
+	if req.Chunking != nil {
+		if err := req.Chunking.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, ScrapeResponse{
+				Success: false,
+				Error:   fmt.Sprintf("❌ Invalid chunking: %v", err),
+			})
+			return nil, false
+		}
+	}
+
+	return &req, true
+}
+
+func (s *Server) scrapingConfig(req *ScrapeRequest, maxPages int) *scraper.ScrapingConfig {
+	return &scraper.ScrapingConfig{
+		MaxDepth:       req.MaxDepth,
+		Delay:          time.Duration(req.Delay) * time.Millisecond,
+		FollowExternal: req.FollowExternal,
+		UserAgent:      "Website-Markdown-API/1.0",
+		MaxPages:       maxPages,
+		MaxTokens:      req.MaxTokens,
+
+		Duplicates:         req.Duplicates,
+		DuplicateThreshold: req.DuplicateThreshold,
+
+		Converter: req.Converter,
+
+		BlockPrivateNetworks: true,
+		AllowedHosts:         s.config.AllowHosts,
+	}
+}
+
+func (s *Server) newJob(c *gin.Context, req *ScrapeRequest) *Job {
+	now := time.Now()
+	job := &Job{
+		ID:        newJobID(),
+		Status:    JobQueued,
+		Owner:     requestOwner(c),
+		Request:   *req,
+		BaseURL:   s.publicBaseURL(c),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.CallbackURL != "" {
+		job.Webhook = &WebhookStatus{URL: req.CallbackURL}
+	}
+	s.saveJob(job)
+	return job
+}
+
+// runJob performs the crawl for job and persists its status, stats and pages.
+// Pages are written to the store as they are scraped and, if sink is not nil,
+// passed on to it. It returns the number of pages scraped.
+func (s *Server) runJob(ctx context.Context, job *Job, maxPages int, sink scraper.PageSink) (int, error) {
+	return s.crawlJob(ctx, job, s.scrapingConfig(&job.Request, maxPages), nil, nil, sink)
+}
+
+// crawlJob runs job's crawl, continuing from state if it is set; stored are
+// the pages the job stored before it was interrupted. The crawl state is
+// checkpointed to the store while it runs, so a job that is cut short by a
+// shutdown can be resumed on the next start. It returns the number of pages
+// this run scraped.
+func (s *Server) crawlJob(ctx context.Context, job *Job, config *scraper.ScrapingConfig, state *scraper.CrawlState, stored []*scraper.ScrapedPage, sink scraper.PageSink) (int, error) {
+	startTime := time.Now()
+	recorder := newStatsRecorder(startTime)
+
+	if state != nil {
+		// Pages stored before the interruption count towards the stats
+		for _, page := range stored {
+			recorder.add(page)
+		}
+		recorder.stats.StartedAt = job.CreatedAt
+	}
+
+	job.Status = JobRunning
+	job.Error = ""
+	job.CompletedAt = nil
+	job.ExpiresAt = nil
+	s.saveJob(job)
+
+	config.Checkpoint = func(state *scraper.CrawlState) error {
+		return s.store.SaveCheckpoint(job.ID, state)
+	}
+
+	scrapeInstance := scraper.NewScraper(config)
+	jobSink := scraper.PageSinkFunc(func(page *scraper.ScrapedPage) error {
+		recorder.add(page)
+		if storeErr := s.store.AppendPages(job.ID, page); storeErr != nil {
+			fmt.Printf("⚠️  Failed to store page %s for job %s: %v\n", page.URL, job.ID, storeErr)
+		}
+		if sink != nil {
+			return sink.WritePage(page)
+		}
+		return nil
+	})
+
+	var err error
+	if state != nil {
+		err = scrapeInstance.Resume(ctx, state, jobSink)
+	} else {
+		err = scrapeInstance.ScrapeTo(ctx, jobSink, job.Request.Seeds()...)
+	}
+
+	endTime := time.Now()
+	job.Stats = recorder.finish(endTime)
+	job.Stats.DuplicateClusters = scrapeInstance.DuplicateClusters()
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		fmt.Printf("🛑 Job %s cancelled by shutdown: %s (%d pages)\n", job.ID, strings.Join(job.Request.Seeds(), ", "), job.Stats.TotalPages)
+		job.Status = JobCancelled
+		job.Error = "Scraping cancelled: server is shutting down"
+	case err != nil:
+		fmt.Printf("❌ Job %s failed: %v\n", job.ID, err)
+		job.Status = JobFailed
+		job.Error = fmt.Sprintf("Scraping failed: %v", err)
+	default:
+		fmt.Printf("✅ Job %s completed: %d pages (%d successful, %d errors) in %v\n",
+			job.ID, job.Stats.TotalPages, job.Stats.SuccessPages, job.Stats.ErrorPages, endTime.Sub(startTime))
+		job.Status = JobCompleted
+	}
+
+	// A job cancelled by shutdown keeps its checkpoint to be resumed
+	if job.Status != JobCancelled {
+		if checkpointErr := s.store.SaveCheckpoint(job.ID, nil); checkpointErr != nil {
+			fmt.Printf("⚠️  Failed to delete checkpoint of job %s: %v\n", job.ID, checkpointErr)
+		}
+	}
+
+	job.CompletedAt = &endTime
+	if ttl := time.Duration(s.config.JobTTL); ttl > 0 {
+		expiresAt := endTime.Add(ttl)
+		job.ExpiresAt = &expiresAt
+	}
+	s.saveJob(job)
+	s.notifyWebhook(job)
+
+	return job.Stats.TotalPages - len(stored), err
+}
+
+// resumeJobs continues the jobs that a previous run of the server left
+// unfinished, from their last checkpoint. Jobs that were running without a
+// checkpoint can't be continued and are marked as failed, as are jobs whose
+// API key has no quota left for the rest of the crawl.
+func (s *Server) resumeJobs() {
+	jobs, err := s.store.ListJobs(JobFilter{})
+	if err != nil {
+		fmt.Printf("⚠️  Failed to list jobs to resume: %v\n", err)
+		return
+	}
+
+	for _, job := range jobs {
+		if job.Status == JobCompleted || job.Status == JobFailed {
+			continue
+		}
+
+		state, err := s.store.GetCheckpoint(job.ID)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to load checkpoint of job %s: %v\n", job.ID, err)
+		}
+		if state == nil {
+			if job.Status != JobCancelled {
+				s.failJob(job, "Scraping interrupted: the server stopped before the job finished")
+			}
+			continue
+		}
+		stored, err := s.store.GetPages(job.ID)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to load pages of job %s: %v\n", job.ID, err)
+			continue
+		}
+		// They are not fetched again, and use up the job's maxPages
+		state.MarkCompleted(stored...)
+
+		ctx, done, ok := s.jobs.begin()
+		if !ok {
+			return
+		}
+
+		maxPages, release, err := s.reserveResumedJob(job, state)
+		if err != nil {
+			done()
+			s.failJob(job, "Scraping interrupted and not resumed: "+strings.TrimPrefix(err.Error(), "❌ "))
+			continue
+		}
+
+		fmt.Printf("⏯️  Resuming job %s: %s\n", job.ID, strings.Join(job.Request.Seeds(), ", "))
+		config := s.scrapingConfig(&job.Request, maxPages)
+		go func(job *Job, state *scraper.CrawlState, stored []*scraper.ScrapedPage) {
+			defer done()
+			count, _ := s.crawlJob(ctx, job, config, state, stored, nil)
+			release(count)
+		}(job, state, stored)
+	}
+}
+
+// reserveResumedJob reserves the pages a resumed job may still scrape from
+// its owner's quota, as acquireQuota does for new jobs: the usage of the
+// previous run is gone with it. It returns the job's page limit, counting
+// the pages already done, and the function to release the reservation.
+func (s *Server) reserveResumedJob(job *Job, state *scraper.CrawlState) (int, func(scraped int), error) {
+	maxPages := state.Config.MaxPages
+	if !s.keys.Enabled() || job.Owner == "" {
+		return maxPages, func(int) {}, nil
+	}
+
+	key := s.keys.LookupName(job.Owner)
+	if key == nil {
+		return 0, nil, fmt.Errorf("API key %s no longer exists", job.Owner)
+	}
+	pages := 0
+	if maxPages > 0 {
+		if pages = maxPages - state.PageCount; pages <= 0 {
+			// Nothing left to scrape, only the frontier to drain
+			return maxPages, func(int) {}, nil
+		}
+	}
+	reserved, err := s.keys.Acquire(key, job.Request.MaxDepth, pages)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	fmt.Printf("🔑 Job %s resumed for API key %s (%d pages reserved)\n", job.ID, key.Name, reserved)
+	return state.PageCount + reserved, func(scraped int) { s.keys.Release(key, reserved, scraped) }, nil
+}
+
+// failJob marks job as failed with reason, without running it.
+func (s *Server) failJob(job *Job, reason string) {
+	now := time.Now()
+	job.Status = JobFailed
+	job.Error = reason
+	job.CompletedAt = &now
+	if ttl := time.Duration(s.config.JobTTL); ttl > 0 {
+		expiresAt := now.Add(ttl)
+		job.ExpiresAt = &expiresAt
+	}
+	s.saveJob(job)
+	fmt.Printf("❌ Job %s: %s\n", job.ID, reason)
+}
+
+// saveJob persists job, logging instead of failing: a store hiccup should
+// not throw away a crawl that is already running.
+func (s *Server) saveJob(job *Job) {
+	job.UpdatedAt = time.Now()
+	if err := s.store.SaveJob(job); err != nil {
+		fmt.Printf("⚠️  Failed to save job %s: %v\n", job.ID, err)
+	}
+}
+
+// submitJob starts a scrape in the background and returns its job ID right away.
+func (s *Server) submitJob(c *gin.Context) {
+	req, ok := s.bindScrapeRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx, done, ok := s.jobs.begin()
+	if !ok {
+		c.Header("Retry-After", "30")
+		c.JSON(http.StatusServiceUnavailable, ScrapeResponse{
+			Success: false,
+			Error:   "❌ Server is shutting down, not accepting new jobs",
+		})
+		return
+	}
+
+	maxPages, release, err := s.acquireQuota(c, req.MaxDepth, req.MaxPages)
+	if err != nil {
+		done()
+		c.JSON(quotaStatus(err), ScrapeResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	fmt.Printf("🔄 API job request: %s (depth: %d, delay: %dms, external: %t)\n",
+		strings.Join(req.Seeds(), ", "), req.MaxDepth, req.Delay, req.FollowExternal)
+
+	job := s.newJob(c, req)
+	go func() {
+		defer done()
+		count, _ := s.runJob(ctx, job, maxPages, nil)
+		release(count)
+	}()
+
+	c.Header("Location", "/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"jobId":   job.ID,
+		"status":  job.Status,
+		"message": "🚀 Job started",
+	})
+}
+
+func (s *Server) listJobs(c *gin.Context) {
+	filter := JobFilter{
+		Owner:  requestOwner(c),
+		Status: JobStatus(c.Query("status")),
+		Limit:  50,
+	}
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if parsed, err := strconv.Atoi(limitParam); err == nil && parsed > 0 {
+			filter.Limit = parsed
+		}
+	}
+
+	jobs, err := s.store.ListJobs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("❌ Failed to list jobs: %v", err),
+		})
+		return
+	}
+
+	for i, job := range jobs {
+		jobs[i] = job.Redacted()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+func (s *Server) getJob(c *gin.Context) {
+	job, ok := s.lookupJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, job.Redacted())
+}
+
+func (s *Server) getJobPages(c *gin.Context) {
+	job, pages, ok := s.lookupJobPages(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, ScrapeResponse{
+		Success: job.Status == JobCompleted,
+		JobID:   job.ID,
+		Error:   job.Error,
+		Pages:   pages,
+		Stats:   job.Stats,
+	})
+}
+
+// downloadJob re-downloads a finished job's results as markdown (default) or JSON.
+func (s *Server) downloadJob(c *gin.Context) {
+	job, pages, ok := s.lookupJobPages(c)
+	if !ok {
+		return
+	}
+
+	baseURL := job.Request.Seeds()[0]
+
+	switch c.DefaultQuery("format", "markdown") {
+	case "json":
+		filename := generateFilename(baseURL, job.CreatedAt, "json")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		// Several seeds are grouped, like the CLI's JSON output
+		if groups := scraper.GroupBySeed(pages); len(groups) > 1 {
+			c.JSON(http.StatusOK, groups)
+			return
+		}
+		c.JSON(http.StatusOK, pages)
+	case "chunks":
+		options := scraper.ChunkOptions{}
+		if job.Request.Chunking != nil {
+			options = *job.Request.Chunking
+		}
+		chunks, err := chunkPages(pages, &options)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		filename := generateFilename(baseURL, job.CreatedAt, "chunks.jsonl")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		encoder := json.NewEncoder(c.Writer)
+		for _, chunk := range chunks {
+			if err := encoder.Encode(chunk); err != nil {
+				fmt.Printf("⚠️  Stopped sending chunks of job %s: %v\n", job.ID, err)
+				return
+			}
+		}
+	case "llms", "llms-full":
+		render, name := scraper.LLMsTxt, scraper.LLMS_TXT
+		if c.Query("format") == "llms-full" {
+			render, name = scraper.LLMsFullTxt, scraper.LLMS_FULL_TXT
+		}
+		var content []string
+		for _, group := range scraper.GroupBySeed(pages) {
+			content = append(content, render(group.Seed, group.Pages))
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(strings.Join(content, "\n")))
+	case "markdown":
+		markdownContent := generateCombinedMarkdown(pages, baseURL, job.CreatedAt)
+		filename := generateFilename(baseURL, job.CreatedAt, "md")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(markdownContent))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "❌ Unsupported format, use markdown, json, chunks, llms or llms-full",
+		})
+	}
+}
+
+// lookupJob loads the job named in the URL. Jobs owned by other API keys
+// are reported as not found.
+func (s *Server) lookupJob(c *gin.Context) (*Job, bool) {
+	job, err := s.store.GetJob(c.Param("id"))
+	if err == nil && job.Owner != requestOwner(c) {
+		err = ErrJobNotFound
+	}
+
+	if errors.Is(err, ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "❌ Job not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("❌ Failed to load job: %v", err),
+		})
+		return nil, false
+	}
+
+	return job, true
+}
+
+func (s *Server) lookupJobPages(c *gin.Context) (*Job, []*scraper.ScrapedPage, bool) {
+	job, ok := s.lookupJob(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if !job.Finished() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "❌ Job is still running",
+			"status": job.Status,
+		})
+		return nil, nil, false
+	}
+
+	pages, err := s.store.GetPages(job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("❌ Failed to load pages: %v", err),
+		})
+		return nil, nil, false
+	}
+
+	return job, pages, true
+}
+
+// cleanupExpiredJobs deletes jobs past their TTL until ctx is cancelled.
+func (s *Server) cleanupExpiredJobs(ctx context.Context) {
+	ticker := time.NewTicker(JOB_CLEANUP_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.store.DeleteExpired(time.Now())
+		if err != nil {
+			fmt.Printf("⚠️  Failed to clean up expired jobs: %v\n", err)
+		} else if deleted > 0 {
+			fmt.Printf("🧹 Deleted %d expired jobs\n", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func openStore(path string) (Store, error) {
+	if path == "" {
+		fmt.Printf("💾 Using in-memory job store (jobs are lost on restart)\n")
+		return NewMemoryStore(), nil
+	}
+
+	fmt.Printf("💾 Using job store: %s\n", path)
+	return NewBoltStore(path)
+}
+
+// publicBaseURL is the externally visible URL of the server, used to build
+// result links. It defaults to the scheme and host of the request.
+func (s *Server) publicBaseURL(c *gin.Context) string {
+	if s.config.PublicURL != "" {
+		return strings.TrimSuffix(s.config.PublicURL, "/")
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// requestOwner returns the API key name of the request, or "" without auth.
+func requestOwner(c *gin.Context) string {
+	if value, exists := c.Get(apiKeyContextKey); exists {
+		return value.(*APIKey).Name
+	}
+	return ""
+}
+
+func newJobID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
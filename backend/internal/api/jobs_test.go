@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"website-markdown/internal/scraper"
+)
+
+// interruptedJob stores a running job of key "ci" with a checkpoint that
+// allows maxPages pages, done of which were already scraped.
+func interruptedJob(t *testing.T, store Store, maxPages, done int) (*Job, *scraper.CrawlState) {
+	t.Helper()
+	job := &Job{ID: "job-1", Status: JobRunning, Owner: "ci", Request: ScrapeRequest{URL: "https://example.com/", MaxDepth: 1}}
+	if err := store.SaveJob(job); err != nil {
+		t.Fatal(err)
+	}
+
+	state := &scraper.CrawlState{Version: scraper.CRAWL_STATE_VERSION, Config: scraper.ScrapingConfig{MaxPages: maxPages}}
+	for i := 0; i < done; i++ {
+		page := &scraper.ScrapedPage{URL: "https://example.com/" + strings.Repeat("a", i+1)}
+		state.Frontier = append(state.Frontier, scraper.FrontierEntry{URL: page.URL})
+		if err := store.AppendPages(job.ID, page); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SaveCheckpoint(job.ID, state); err != nil {
+		t.Fatal(err)
+	}
+	return job, state
+}
+
+func TestResumedJobReservesTheRestOfItsPages(t *testing.T) {
+	keys, err := NewKeyStore([]*APIKey{{Key: "s3cret-key-one", Name: "ci", PagesPerDay: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryStore()
+	server := NewServer(nil, keys, store)
+	job, state := interruptedJob(t, store, 50, 20)
+	state.MarkCompleted(mustGetPages(t, store, job.ID)...)
+
+	maxPages, release, err := server.reserveResumedJob(job, state)
+	if err != nil || maxPages != 50 {
+		t.Fatalf("reserveResumedJob() = %d, %v, want a limit of 50", maxPages, err)
+	}
+
+	// Only the 30 pages left came out of the quota
+	key := keys.LookupName("ci")
+	if reserved, err := keys.Acquire(key, 1, 0); err != nil || reserved != 70 {
+		t.Fatalf("Acquire(0) while resumed = %d, %v, want the 70 pages left", reserved, err)
+	}
+	release(10)
+	if usage := keys.usage[key.Key]; usage.pagesToday != 80 || usage.activeJobs != 1 {
+		t.Errorf("usage after the release = %d pages, %d jobs, want 80 and 1", usage.pagesToday, usage.activeJobs)
+	}
+}
+
+func TestResumeJobsFailsJobsWithoutQuota(t *testing.T) {
+	keys, err := NewKeyStore([]*APIKey{{Key: "s3cret-key-one", Name: "ci", PagesPerDay: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Acquire(keys.LookupName("ci"), 1, 100); err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryStore()
+	server := NewServer(nil, keys, store)
+	interruptedJob(t, store, 50, 0)
+
+	server.resumeJobs()
+
+	job, err := store.GetJob("job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != JobFailed || !strings.Contains(job.Error, "quota") {
+		t.Errorf("job = %s (%q), want failed for the exhausted quota", job.Status, job.Error)
+	}
+}
+
+func mustGetPages(t *testing.T, store Store, id string) []*scraper.ScrapedPage {
+	t.Helper()
+	pages, err := store.GetPages(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pages
+}
+
+func TestJobEndpoints(t *testing.T) {
+	keys, err := NewKeyStore([]*APIKey{{Key: "s3cret-key-one", Name: "ci"}, {Key: "s3cret-key-two", Name: "docs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryStore()
+	server := NewServer(nil, keys, store)
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	request := ScrapeRequest{URL: "https://example.com/", CallbackSecret: "hush"}
+	for _, job := range []*Job{
+		{ID: "done", Owner: "ci", Status: JobCompleted, Request: request, CreatedAt: created},
+		{ID: "busy", Owner: "ci", Status: JobRunning, Request: request, CreatedAt: created.Add(time.Hour)},
+	} {
+		if err := store.SaveJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AppendPages("done", &scraper.ScrapedPage{URL: "https://example.com/", Title: "Home", Markdown: "# Home"}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(key, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	var list struct {
+		Jobs []*Job `json:"jobs"`
+	}
+	recorder := get("s3cret-key-one", "/jobs")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &list); err != nil || len(list.Jobs) != 2 || list.Jobs[0].ID != "busy" {
+		t.Fatalf("GET /jobs = %s, want both jobs, newest first", recorder.Body.String())
+	}
+	if strings.Contains(recorder.Body.String(), "hush") {
+		t.Error("GET /jobs shows the callback secret")
+	}
+
+	var pages []*scraper.ScrapedPage
+	recorder = get("s3cret-key-one", "/jobs/done/download?format=json")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &pages); err != nil || len(pages) != 1 || pages[0].Title != "Home" {
+		t.Errorf("download as JSON = %d %s, want the stored page", recorder.Code, recorder.Body.String())
+	}
+	if recorder = get("s3cret-key-one", "/jobs/busy/download"); recorder.Code != http.StatusConflict {
+		t.Errorf("download of a running job = %d, want 409", recorder.Code)
+	}
+	// Other keys can't see the job
+	if recorder = get("s3cret-key-two", "/jobs/done"); recorder.Code != http.StatusNotFound {
+		t.Errorf("GET /jobs/done with another key = %d, want 404", recorder.Code)
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -16,20 +18,59 @@ import (
 
 type Server struct {
 	router *gin.Engine
-	port   string
+	config *ServerConfig
+	keys   *KeyStore
+	jobs   *jobTracker
+	store  Store
 }
 
+// ScrapeRequest starts a crawl from url, urls, or both. All seeds share one
+// visited set and page budget; each keeps its own host scope.
 type ScrapeRequest struct {
-	URL            string `json:"url" binding:"required"`
-	MaxDepth       int    `json:"maxDepth"`
-	Delay          int    `json:"delay"`
-	FollowExternal bool   `json:"followExternal"`
+	URL            string   `json:"url,omitempty"`
+	URLs           []string `json:"urls,omitempty"`
+	MaxDepth       int      `json:"maxDepth"`
+	Delay          int      `json:"delay"`
+	FollowExternal bool     `json:"followExternal"`
+	MaxTokens      int      `json:"maxTokens,omitempty"` // stop once the pages add up to this many tokens
+	MaxPages       int      `json:"maxPages,omitempty"`  // stop after this many pages; reserved from the API key's daily quota
+
+	// Optional near-duplicate handling: off (default), group or drop
+	Duplicates         string  `json:"duplicates,omitempty"`
+	DuplicateThreshold float64 `json:"duplicateThreshold,omitempty"`
+
+	// Optional Markdown style: heading style, bullet marker, fence, link
+	// style and whether to use GitHub Flavored Markdown
+	Converter scraper.ConverterOptions `json:"converter"`
+
+	// Optional webhook called when the job finishes or fails
+	CallbackURL    string `json:"callbackUrl,omitempty"`
+	CallbackSecret string `json:"callbackSecret,omitempty"`
+
+	// Optional: also split the pages into chunks for RAG ingestion
+	Chunking *scraper.ChunkOptions `json:"chunking,omitempty"`
+}
+
+// Seeds returns the request's seed URLs without duplicates, url first.
+func (r *ScrapeRequest) Seeds() []string {
+	var seeds []string
+	seen := make(map[string]bool)
+	for _, seed := range append([]string{r.URL}, r.URLs...) {
+		seed = strings.TrimSpace(seed)
+		if seed != "" && !seen[seed] {
+			seen[seed] = true
+			seeds = append(seeds, seed)
+		}
+	}
+	return seeds
 }
 
 type ScrapeResponse struct {
 	Success bool                   `json:"success"`
+	JobID   string                 `json:"jobId,omitempty"`
 	Message string                 `json:"message"`
 	Pages   []*scraper.ScrapedPage `json:"pages,omitempty"`
+	Chunks  []scraper.Chunk        `json:"chunks,omitempty"`
 	Error   string                 `json:"error,omitempty"`
 	Stats   *ScrapeStats           `json:"stats,omitempty"`
 }
@@ -38,27 +79,62 @@ type ScrapeStats struct {
 	TotalPages     int       `json:"totalPages"`
 	SuccessPages   int       `json:"successPages"`
 	ErrorPages     int       `json:"errorPages"`
+	TotalChars     int       `json:"totalChars"`
+	TotalWords     int       `json:"totalWords"`
+	TotalTokens    int       `json:"totalTokens"`
 	ProcessingTime string    `json:"processingTime"`
 	StartedAt      time.Time `json:"startedAt"`
 	CompletedAt    time.Time `json:"completedAt"`
+
+	// Per-seed breakdown, only set when several seeds were scraped
+	Seeds []SeedStats `json:"seeds,omitempty"`
+
+	// Near-duplicate clusters, when duplicate detection is on
+	DuplicateClusters []scraper.DuplicateCluster `json:"duplicateClusters,omitempty"`
 }
 
-func NewServer(port string) *Server {
+type SeedStats struct {
+	Seed         string `json:"seed"`
+	TotalPages   int    `json:"totalPages"`
+	SuccessPages int    `json:"successPages"`
+	ErrorPages   int    `json:"errorPages"`
+	TotalTokens  int    `json:"totalTokens"`
+}
+
+func NewServer(config *ServerConfig, keys *KeyStore, store Store) *Server {
+	if config == nil {
+		config = DefaultServerConfig()
+	}
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
 	// Set gin mode
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.Default()
 
 	// CORS middleware
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:5173", "http://localhost:4173"} // Vite dev and preview ports
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	router.Use(cors.New(config))
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = config.AllowedOrigins
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	router.Use(cors.New(corsConfig))
+
+	// Request body size limit
+	if config.MaxBodyBytes > 0 {
+		router.Use(func(c *gin.Context) {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxBodyBytes)
+			c.Next()
+		})
+	}
 
 	server := &Server{
 		router: router,
-		port:   port,
+		config: config,
+		keys:   keys,
+		jobs:   newJobTracker(),
+		store:  store,
 	}
 
 	server.setupRoutes()
@@ -70,24 +146,70 @@ func (s *Server) setupRoutes() {
 	s.router.GET("/health", s.healthCheck)
 
 	// API routes
-	s.router.POST("/scrape", s.scrapeWebsite)
-	s.router.GET("/download/markdown", s.downloadMarkdown)
+	s.router.POST("/scrape", s.requireAPIKey(), s.acceptJobs(), s.scrapeWebsite)
+	s.router.GET("/download/markdown", s.requireAPIKey(), s.acceptJobs(), s.downloadMarkdown)
+	s.router.POST("/convert", s.requireAPIKey(), s.acceptJobs(), s.convertPage)
 	s.router.GET("/status", s.getStatus)
 
+	// Job routes
+	jobs := s.router.Group("/jobs", s.requireAPIKey())
+	jobs.POST("", s.submitJob)
+	jobs.GET("", s.listJobs)
+	jobs.GET("/:id", s.getJob)
+	jobs.GET("/:id/pages", s.getJobPages)
+	jobs.GET("/:id/download", s.downloadJob)
+
 	// Serve static files for docs (optional)
 	s.router.Static("/docs", "./docs")
 }
 
 func (s *Server) Start() error {
-	fmt.Printf("🚀 Starting API server on port %s\n", s.port)
+	fmt.Printf("🚀 Starting API server on %s\n", s.config.Address())
 	fmt.Printf("📝 API Endpoints:\n")
 	fmt.Printf("   POST /scrape - Scrape a website\n")
 	fmt.Printf("   GET  /download/markdown - Download scraped content as markdown\n")
+	fmt.Printf("   POST /convert - Convert raw HTML or a single URL\n")
+	fmt.Printf("   POST /jobs - Start a background scrape job\n")
+	fmt.Printf("   GET  /jobs - List past jobs\n")
+	fmt.Printf("   GET  /jobs/:id - Get job status and stats\n")
+	fmt.Printf("   GET  /jobs/:id/pages - Get a job's scraped pages\n")
+	fmt.Printf("   GET  /jobs/:id/download - Download a job's results (markdown or json)\n")
 	fmt.Printf("   GET  /status - Get server status\n")
 	fmt.Printf("   GET  /health - Health check\n")
-	fmt.Printf("🌐 CORS enabled for frontend on localhost:5173 and localhost:4173\n")
+	fmt.Printf("🌐 CORS enabled for: %s\n", strings.Join(s.config.AllowedOrigins, ", "))
+	fmt.Printf("🛡️  Private network addresses blocked (allowlist: %d entries)\n", len(s.config.AllowHosts))
+	if s.keys.Enabled() {
+		fmt.Printf("🔑 API key authentication enabled (%d keys)\n", len(s.keys.keys))
+	} else {
+		fmt.Printf("⚠️  API key authentication disabled - /scrape is open to anyone\n")
+	}
+
+	httpServer := &http.Server{
+		Addr:         s.config.Address(),
+		Handler:      s.router,
+		ReadTimeout:  time.Duration(s.config.ReadTimeout),
+		WriteTimeout: time.Duration(s.config.WriteTimeout),
+	}
+
+	if s.config.TLSEnabled() {
+		fmt.Printf("🔒 TLS enabled\n")
+	}
+
+	// Pick up jobs interrupted by the last shutdown before expired ones are
+	// cleaned up
+	s.resumeJobs()
+
+	retentionCtx, stopRetention := context.WithCancel(context.Background())
+	go s.cleanupExpiredJobs(retentionCtx)
 
-	return s.router.Run(":" + s.port)
+	err := s.serveUntilSignal(httpServer)
+	stopRetention()
+
+	// All jobs have saved their final state by now
+	if closeErr := s.store.Close(); closeErr != nil {
+		fmt.Printf("⚠️  Failed to close job store: %v\n", closeErr)
+	}
+	return err
 }
 
 func (s *Server) healthCheck(c *gin.Context) {
@@ -102,9 +224,16 @@ func (s *Server) getStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "running",
 		"version": "1.0.0",
+		"auth":    s.keys.Enabled(),
 		"endpoints": []string{
 			"POST /scrape",
 			"GET /download/markdown",
+			"POST /convert",
+			"POST /jobs",
+			"GET /jobs",
+			"GET /jobs/:id",
+			"GET /jobs/:id/pages",
+			"GET /jobs/:id/download",
 			"GET /status",
 			"GET /health",
 		},
@@ -146,6 +275,14 @@ func (s *Server) downloadMarkdown(c *gin.Context) {
 		followExternal = true
 	}
 
+	maxPages, release, err := s.acquireQuota(c, maxDepth, 0)
+	if err != nil {
+		c.JSON(quotaStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	fmt.Printf("🔄 API markdown download: %s (depth: %d, delay: %dms, external: %t)\n",
 		urlParam, maxDepth, delay, followExternal)
 
@@ -155,15 +292,27 @@ func (s *Server) downloadMarkdown(c *gin.Context) {
 		Delay:          time.Duration(delay) * time.Millisecond,
 		FollowExternal: followExternal,
 		UserAgent:      "Website-Markdown-API/1.0",
+		MaxPages:       maxPages,
+
+		BlockPrivateNetworks: true,
+		AllowedHosts:         s.config.AllowHosts,
 	}
 
 	// Perform scraping
 	scrapeInstance := scraper.NewScraper(config)
-	pages, err := scrapeInstance.ScrapeWebsite(urlParam)
+	pages, err := scrapeInstance.ScrapeWebsiteContext(jobContext(c), urlParam)
+	release(len(pages))
 
+	if errors.Is(err, context.Canceled) {
+		fmt.Printf("🛑 Scraping cancelled by shutdown: %s\n", urlParam)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "❌ Scraping cancelled: server is shutting down",
+		})
+		return
+	}
 	if err != nil {
 		fmt.Printf("❌ Scraping failed: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(scrapeErrorStatus(err), gin.H{
 			"error": fmt.Sprintf("Scraping failed: %v", err),
 		})
 		return
@@ -173,7 +322,7 @@ func (s *Server) downloadMarkdown(c *gin.Context) {
 	markdownContent := generateCombinedMarkdown(pages, urlParam, startTime)
 
 	// Generate filename
-	filename := generateMarkdownFilename(urlParam)
+	filename := generateFilename(urlParam, startTime, "md")
 
 	// Set headers for file download
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
@@ -185,115 +334,186 @@ func (s *Server) downloadMarkdown(c *gin.Context) {
 }
 
 func (s *Server) scrapeWebsite(c *gin.Context) {
-	startTime := time.Now()
+	req, ok := s.bindScrapeRequest(c)
+	if !ok {
+		return
+	}
 
-	var req ScrapeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ScrapeResponse{
+	maxPages, release, err := s.acquireQuota(c, req.MaxDepth, req.MaxPages)
+	if err != nil {
+		c.JSON(quotaStatus(err), ScrapeResponse{
 			Success: false,
-			Error:   fmt.Sprintf("❌ Invalid request: %v", err),
+			Error:   err.Error(),
 		})
 		return
 	}
 
-	// Validate URL
-	if req.URL == "" {
-		c.JSON(http.StatusBadRequest, ScrapeResponse{
+	fmt.Printf("🔄 API scrape request: %s (depth: %d, delay: %dms, external: %t)\n",
+		strings.Join(req.Seeds(), ", "), req.MaxDepth, req.Delay, req.FollowExternal)
+
+	// The response carries every page, so collect them as they stream in
+	var pages []*scraper.ScrapedPage
+	job := s.newJob(c, req)
+	count, err := s.runJob(jobContext(c), job, maxPages, scraper.PageSinkFunc(func(page *scraper.ScrapedPage) error {
+		pages = append(pages, page)
+		return nil
+	}))
+	release(count)
+
+	if errors.Is(err, context.Canceled) {
+		// Return what we have instead of dropping the work on the floor
+		c.JSON(http.StatusServiceUnavailable, ScrapeResponse{
 			Success: false,
-			Error:   "❌ URL is required",
+			JobID:   job.ID,
+			Error:   "❌ Scraping cancelled: server is shutting down",
+			Pages:   pages,
+			Stats:   job.Stats,
 		})
 		return
 	}
 
-	// Set defaults
-	if req.MaxDepth <= 0 {
-		req.MaxDepth = 3
-	}
-	if req.MaxDepth > 10 {
-		req.MaxDepth = 10 // Prevent abuse
-	}
-	if req.Delay <= 0 {
-		req.Delay = 1000
-	}
-	if req.Delay < 500 {
-		req.Delay = 500 // Minimum delay to be respectful
-	}
-
-	fmt.Printf("🔄 API scrape request: %s (depth: %d, delay: %dms, external: %t)\n",
-		req.URL, req.MaxDepth, req.Delay, req.FollowExternal)
-
-	// Create scraper config
-	config := &scraper.ScrapingConfig{
-		MaxDepth:       req.MaxDepth,
-		Delay:          time.Duration(req.Delay) * time.Millisecond,
-		FollowExternal: req.FollowExternal,
-		UserAgent:      "Website-Markdown-API/1.0",
+	if err != nil {
+		c.JSON(scrapeErrorStatus(err), ScrapeResponse{
+			Success: false,
+			JobID:   job.ID,
+			Error:   job.Error,
+			Stats:   job.Stats,
+		})
+		return
 	}
 
-	// Perform scraping
-	scrapeInstance := scraper.NewScraper(config)
-	pages, err := scrapeInstance.ScrapeWebsite(req.URL)
-
-	endTime := time.Now()
-	processingTime := endTime.Sub(startTime)
-
+	// The options were validated with the request
+	chunks, err := chunkPages(pages, req.Chunking)
 	if err != nil {
-		fmt.Printf("❌ Scraping failed: %v\n", err)
 		c.JSON(http.StatusInternalServerError, ScrapeResponse{
 			Success: false,
-			Error:   fmt.Sprintf("Scraping failed: %v", err),
-			Stats: &ScrapeStats{
-				ProcessingTime: processingTime.String(),
-				StartedAt:      startTime,
-				CompletedAt:    endTime,
-			},
+			JobID:   job.ID,
+			Error:   err.Error(),
+			Stats:   job.Stats,
 		})
 		return
 	}
 
-	// Calculate stats
-	stats := calculateStats(pages, startTime, endTime)
-
-	fmt.Printf("✅ Scraping completed: %d pages (%d successful, %d errors) in %v\n",
-		stats.TotalPages, stats.SuccessPages, stats.ErrorPages, processingTime)
-
 	c.JSON(http.StatusOK, ScrapeResponse{
 		Success: true,
-		Message: fmt.Sprintf("🎉 Successfully scraped %d pages", stats.SuccessPages),
+		JobID:   job.ID,
+		Message: fmt.Sprintf("🎉 Successfully scraped %d pages", job.Stats.SuccessPages),
 		Pages:   pages,
-		Stats:   stats,
+		Chunks:  chunks,
+		Stats:   job.Stats,
 	})
 }
 
-func calculateStats(pages []*scraper.ScrapedPage, startTime, endTime time.Time) *ScrapeStats {
-	stats := &ScrapeStats{
-		TotalPages:     len(pages),
-		ProcessingTime: endTime.Sub(startTime).String(),
-		StartedAt:      startTime,
-		CompletedAt:    endTime,
+// chunkPages splits the successfully scraped pages when chunking was asked
+// for.
+func chunkPages(pages []*scraper.ScrapedPage, options *scraper.ChunkOptions) ([]scraper.Chunk, error) {
+	if options == nil {
+		return nil, nil
 	}
-
+	var chunks []scraper.Chunk
 	for _, page := range pages {
 		if page.Error != "" {
-			stats.ErrorPages++
-		} else {
-			stats.SuccessPages++
+			continue
+		}
+		pageChunks, err := scraper.ChunkPage(page, *options)
+		if err != nil {
+			return nil, fmt.Errorf("❌ Invalid chunking: %v", err)
 		}
+		chunks = append(chunks, pageChunks...)
 	}
+	return chunks, nil
+}
+
+func quotaStatus(err error) int {
+	if quotaErr, ok := err.(*QuotaError); ok {
+		return quotaErr.Status
+	}
+	return http.StatusInternalServerError
+}
+
+func bindErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func scrapeErrorStatus(err error) int {
+	var blockedErr *scraper.BlockedAddressError
+	if errors.As(err, &blockedErr) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
 
-	return stats
+// statsRecorder builds ScrapeStats as pages stream in, so a job's stats
+// don't require holding all of its pages.
+type statsRecorder struct {
+	stats     ScrapeStats
+	seedIndex map[string]int // seed -> position in stats.Seeds
+}
+
+func newStatsRecorder(startTime time.Time) *statsRecorder {
+	return &statsRecorder{
+		stats:     ScrapeStats{StartedAt: startTime},
+		seedIndex: make(map[string]int),
+	}
+}
+
+func (r *statsRecorder) add(page *scraper.ScrapedPage) {
+	i, exists := r.seedIndex[page.Seed]
+	if !exists {
+		i = len(r.stats.Seeds)
+		r.seedIndex[page.Seed] = i
+		r.stats.Seeds = append(r.stats.Seeds, SeedStats{Seed: page.Seed})
+	}
+	seedStats := &r.stats.Seeds[i]
+
+	r.stats.TotalPages++
+	seedStats.TotalPages++
+	if page.Error != "" {
+		r.stats.ErrorPages++
+		seedStats.ErrorPages++
+	} else {
+		r.stats.SuccessPages++
+		seedStats.SuccessPages++
+	}
+
+	r.stats.TotalChars += page.Chars
+	r.stats.TotalWords += page.Words
+	r.stats.TotalTokens += page.Tokens
+	seedStats.TotalTokens += page.Tokens
+}
+
+func (r *statsRecorder) finish(endTime time.Time) *ScrapeStats {
+	stats := r.stats
+	stats.ProcessingTime = endTime.Sub(stats.StartedAt).String()
+	stats.CompletedAt = endTime
+	// The per-seed breakdown only adds noise for a single seed
+	if len(stats.Seeds) <= 1 {
+		stats.Seeds = nil
+	}
+	return &stats
 }
 
 func generateCombinedMarkdown(pages []*scraper.ScrapedPage, baseURL string, scrapeTime time.Time) string {
 	var content strings.Builder
+	groups := scraper.GroupBySeed(pages)
+	multipleSeeds := len(groups) > 1
 
 	// Table of Contents
 	content.WriteString("# Table of Contents\n\n")
 	pageNum := 1
-	for _, page := range pages {
-		if page.Error == "" {
-			content.WriteString(fmt.Sprintf("%d. [%s](#page-%d)\n", pageNum, page.Title, pageNum))
-			pageNum++
+	for _, group := range groups {
+		if multipleSeeds {
+			content.WriteString(fmt.Sprintf("\n**%s**\n\n", group.Seed))
+		}
+		for _, page := range group.Pages {
+			if page.Error == "" {
+				content.WriteString(fmt.Sprintf("%d. [%s](#page-%d)\n", pageNum, page.Title, pageNum))
+				pageNum++
+			}
 		}
 	}
 
@@ -301,26 +521,33 @@ func generateCombinedMarkdown(pages []*scraper.ScrapedPage, baseURL string, scra
 
 	// Content sections
 	pageNum = 1
-	for _, page := range pages {
-		if page.Error != "" {
-			continue // Skip error pages in main content
+	for _, group := range groups {
+		if multipleSeeds {
+			content.WriteString(fmt.Sprintf("# %s\n\n", group.Seed))
+		}
+		for _, page := range group.Pages {
+			if page.Error != "" {
+				continue // Skip error pages in main content
+			}
+
+			content.WriteString(fmt.Sprintf("## %s {#page-%d}\n\n", page.Title, pageNum))
+			// Each page numbers its referenced links from 1
+			content.WriteString(scraper.InlineLinkReferences(page.Markdown))
+			content.WriteString("\n\n---\n\n")
+			pageNum++
 		}
-
-		content.WriteString(fmt.Sprintf("## %s {#page-%d}\n\n", page.Title, pageNum))
-		content.WriteString(page.Markdown)
-		content.WriteString("\n\n---\n\n")
-		pageNum++
 	}
 
 	return content.String()
 }
 
-func generateMarkdownFilename(websiteURL string) string {
+func generateFilename(websiteURL string, scrapeTime time.Time, extension string) string {
+	timestamp := scrapeTime.Format("2006-01-02_15-04-05")
+
 	parsedURL, err := url.Parse(websiteURL)
 	if err != nil {
 		// Fallback if URL parsing fails
-		timestamp := time.Now().Format("2006-01-02_15-04-05")
-		return fmt.Sprintf("website_%s.md", timestamp)
+		return fmt.Sprintf("website_%s.%s", timestamp, extension)
 	}
 
 	// Get hostname and remove www. prefix
@@ -330,23 +557,25 @@ func generateMarkdownFilename(websiteURL string) string {
 	// Clean up the site name to be filesystem-safe
 	siteName = strings.ReplaceAll(siteName, ".", "-")
 
-	// Generate timestamp
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-
-	return fmt.Sprintf("%s_%s.md", siteName, timestamp)
+	return fmt.Sprintf("%s_%s.%s", siteName, timestamp, extension)
 }
 
 // Helper function to start server from main
-func StartAPIServer(port string) error {
-	if port == "" {
-		port = "8080"
+func StartAPIServer(config *ServerConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
 	}
 
-	// Validate port
-	if _, err := strconv.Atoi(port); err != nil {
-		return fmt.Errorf("❌ Invalid port: %s", port)
+	keys, err := LoadAPIKeys(config.APIKeysFile)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(config.StorePath)
+	if err != nil {
+		return err
 	}
 
-	server := NewServer(port)
+	server := NewServer(config, keys, store)
 	return server.Start()
 }
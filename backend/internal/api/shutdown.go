@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jobContextKey holds the context of the job a request started
+const jobContextKey = "jobContext"
+
+// jobTracker keeps count of running crawls so shutdown can wait for them
+// and cancel whatever is left once the drain period is over.
+type jobTracker struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mutex    sync.Mutex
+	wg       sync.WaitGroup
+	active   int
+	draining bool
+}
+
+func newJobTracker() *jobTracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &jobTracker{ctx: ctx, cancel: cancel}
+}
+
+// begin registers a new job. It returns the job's context and a function to
+// call when the job is done, or ok=false when the server is draining.
+func (t *jobTracker) begin() (ctx context.Context, done func(), ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.draining {
+		return nil, nil, false
+	}
+
+	t.active++
+	t.wg.Add(1)
+
+	var once sync.Once
+	return t.ctx, func() {
+		once.Do(func() {
+			t.mutex.Lock()
+			t.active--
+			t.mutex.Unlock()
+			t.wg.Done()
+		})
+	}, true
+}
+
+// track registers follow-up work of an already accepted job, such as a
+// webhook delivery. Unlike begin it also works while draining.
+func (t *jobTracker) track() (context.Context, func()) {
+	t.wg.Add(1)
+
+	var once sync.Once
+	return t.ctx, func() {
+		once.Do(t.wg.Done)
+	}
+}
+
+func (t *jobTracker) activeJobs() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.active
+}
+
+// drain stops new jobs and waits up to timeout for running ones. Jobs still
+// running after that are cancelled through their context, and drain waits
+// for them to wrap up until ctx expires.
+func (t *jobTracker) drain(ctx context.Context, timeout time.Duration) {
+	t.mutex.Lock()
+	t.draining = true
+	t.mutex.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return
+	case <-time.After(timeout):
+		fmt.Printf("⏱️  Drain period of %v exceeded, cancelling %d running crawls\n", timeout, t.activeJobs())
+		t.cancel()
+	}
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		fmt.Printf("⚠️  %d crawls did not stop in time\n", t.activeJobs())
+	}
+}
+
+// acceptJobs rejects new crawl requests with 503 while the server drains.
+func (s *Server) acceptJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, done, ok := s.jobs.begin()
+		if !ok {
+			c.Header("Retry-After", "30")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"error":   "❌ Server is shutting down, not accepting new jobs",
+			})
+			return
+		}
+		defer done()
+
+		c.Set(jobContextKey, ctx)
+		c.Next()
+	}
+}
+
+// jobContext returns the context crawls started by this request must use.
+func jobContext(c *gin.Context) context.Context {
+	if value, exists := c.Get(jobContextKey); exists {
+		return value.(context.Context)
+	}
+	return context.Background()
+}
+
+// serveUntilSignal runs httpServer until SIGINT/SIGTERM, then shuts down
+// gracefully: new jobs are refused, running crawls get the drain period to
+// finish and are cancelled afterwards.
+func (s *Server) serveUntilSignal(httpServer *http.Server) error {
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.config.TLSEnabled() {
+			serveErr <- httpServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+		} else {
+			serveErr <- httpServer.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-signalCtx.Done():
+	}
+	stop() // A second signal kills the process
+
+	drainTimeout := time.Duration(s.config.DrainTimeout)
+	fmt.Printf("🛑 Shutting down, waiting up to %v for %d running crawls...\n", drainTimeout, s.jobs.activeJobs())
+
+	// Give cancelled crawls a little extra time to return their partial results
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout+SHUTDOWN_GRACE_PERIOD)
+	defer cancel()
+
+	// Stop accepting connections while the running handlers finish
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- httpServer.Shutdown(ctx)
+	}()
+
+	s.jobs.drain(ctx, drainTimeout)
+
+	if err := <-shutdownErr; err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	fmt.Println("👋 Server stopped")
+	return nil
+}
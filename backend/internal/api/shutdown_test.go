@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestJobTrackerDrainWaitsForJobs(t *testing.T) {
+	tracker := newJobTracker()
+	ctx, done, ok := tracker.begin()
+	if !ok {
+		t.Fatal("begin() refused a job before draining")
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		done()
+	}()
+
+	tracker.drain(context.Background(), time.Minute)
+	if ctx.Err() != nil {
+		t.Error("drain() cancelled a job that finished within the drain period")
+	}
+	if _, _, ok := tracker.begin(); ok {
+		t.Error("begin() accepted a job while draining")
+	}
+}
+
+func TestJobTrackerDrainCancelsLateJobs(t *testing.T) {
+	tracker := newJobTracker()
+	ctx, done, _ := tracker.begin()
+	go func() {
+		<-ctx.Done()
+		done()
+	}()
+
+	// Follow-up work of accepted jobs is still tracked while draining
+	followUp, finish := tracker.track()
+	go func() {
+		<-followUp.Done()
+		finish()
+	}()
+
+	finished := make(chan struct{})
+	go func() {
+		tracker.drain(context.Background(), 10*time.Millisecond)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain() didn't cancel the running job")
+	}
+	if tracker.activeJobs() != 0 {
+		t.Errorf("%d jobs still active after drain()", tracker.activeJobs())
+	}
+}
+
+func TestAcceptJobsWhileDraining(t *testing.T) {
+	server := NewServer(nil, nil, nil)
+	server.jobs.drain(context.Background(), 0)
+
+	recorder := postConvert(server, "", "text/html", convertTestPage)
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("Retry-After") == "" {
+		t.Errorf("convert while draining = %d, want 503 with Retry-After", recorder.Code)
+	}
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"website-markdown/internal/scraper"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+// Job is the persisted record of a single crawl. Pages are stored separately
+// so jobs can be listed without loading their results.
+type Job struct {
+	ID          string         `json:"id"`
+	Status      JobStatus      `json:"status"`
+	Owner       string         `json:"owner,omitempty"` // API key name, if auth is enabled
+	Request     ScrapeRequest  `json:"request"`
+	Stats       *ScrapeStats   `json:"stats,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	BaseURL     string         `json:"baseUrl,omitempty"` // public server URL, used for result links
+	Webhook     *WebhookStatus `json:"webhook,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	CompletedAt *time.Time     `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time     `json:"expiresAt,omitempty"` // unset while the job is running
+}
+
+func (j *Job) Finished() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// Redacted returns a copy of the job that is safe to show to API clients.
+func (j *Job) Redacted() *Job {
+	copied := *j
+	if copied.Request.CallbackSecret != "" {
+		copied.Request.CallbackSecret = "********"
+	}
+	return &copied
+}
+
+// JobFilter narrows down ListJobs. Zero values match everything.
+type JobFilter struct {
+	Owner  string
+	Status JobStatus
+	Limit  int
+}
+
+func (f JobFilter) matches(job *Job) bool {
+	if f.Owner != "" && job.Owner != f.Owner {
+		return false
+	}
+	if f.Status != "" && job.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+// Store persists jobs and their scraped pages.
+type Store interface {
+	SaveJob(job *Job) error
+	GetJob(id string) (*Job, error)
+	// ListJobs returns matching jobs, newest first
+	ListJobs(filter JobFilter) ([]*Job, error)
+	AppendPages(id string, pages ...*scraper.ScrapedPage) error
+	GetPages(id string) ([]*scraper.ScrapedPage, error)
+	// SaveCheckpoint stores the crawl state of a running job; nil deletes it
+	SaveCheckpoint(id string, state *scraper.CrawlState) error
+	// GetCheckpoint returns nil if the job has no checkpoint
+	GetCheckpoint(id string) (*scraper.CrawlState, error)
+	// DeleteExpired removes finished jobs whose ExpiresAt is before now
+	DeleteExpired(now time.Time) (int, error)
+	Close() error
+}
+
+// MemoryStore keeps jobs in memory. Everything is lost on restart.
+type MemoryStore struct {
+	jobs        map[string]*Job
+	pages       map[string][]*scraper.ScrapedPage
+	checkpoints map[string]*scraper.CrawlState
+	mutex       sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:        make(map[string]*Job),
+		pages:       make(map[string][]*scraper.ScrapedPage),
+		checkpoints: make(map[string]*scraper.CrawlState),
+	}
+}
+
+func (m *MemoryStore) SaveJob(job *Job) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *MemoryStore) GetJob(id string) (*Job, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	job, exists := m.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	copied := *job
+	return &copied, nil
+}
+
+func (m *MemoryStore) ListJobs(filter JobFilter) ([]*Job, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var jobs []*Job
+	for _, job := range m.jobs {
+		if filter.matches(job) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+
+	return limitJobs(sortJobs(jobs), filter.Limit), nil
+}
+
+func (m *MemoryStore) AppendPages(id string, pages ...*scraper.ScrapedPage) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.jobs[id]; !exists {
+		return ErrJobNotFound
+	}
+
+	m.pages[id] = append(m.pages[id], pages...)
+	return nil
+}
+
+func (m *MemoryStore) GetPages(id string) ([]*scraper.ScrapedPage, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if _, exists := m.jobs[id]; !exists {
+		return nil, ErrJobNotFound
+	}
+
+	return append([]*scraper.ScrapedPage(nil), m.pages[id]...), nil
+}
+
+func (m *MemoryStore) SaveCheckpoint(id string, state *scraper.CrawlState) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if state == nil {
+		delete(m.checkpoints, id)
+		return nil
+	}
+	if _, exists := m.jobs[id]; !exists {
+		return ErrJobNotFound
+	}
+
+	m.checkpoints[id] = state
+	return nil
+}
+
+func (m *MemoryStore) GetCheckpoint(id string) (*scraper.CrawlState, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.checkpoints[id], nil
+}
+
+func (m *MemoryStore) DeleteExpired(now time.Time) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	deleted := 0
+	for id, job := range m.jobs {
+		if job.ExpiresAt != nil && job.ExpiresAt.Before(now) {
+			delete(m.jobs, id)
+			delete(m.pages, id)
+			delete(m.checkpoints, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+func sortJobs(jobs []*Job) []*Job {
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+func limitJobs(jobs []*Job, limit int) []*Job {
+	if limit > 0 && len(jobs) > limit {
+		return jobs[:limit]
+	}
+	return jobs
+}
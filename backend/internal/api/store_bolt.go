@@ -0,0 +1,218 @@
+package api
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"website-markdown/internal/scraper"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	jobsBucket        = []byte("jobs")
+	pagesBucket       = []byte("pages") // one nested bucket per job, keyed by sequence
+	checkpointsBucket = []byte("checkpoints")
+)
+
+// BoltStore persists jobs and pages in an embedded BoltDB file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to open job store %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{jobsBucket, pagesBucket, checkpointsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("❌ Failed to initialize job store: %v", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (b *BoltStore) SaveJob(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(job.ID), data)
+	})
+}
+
+func (b *BoltStore) GetJob(id string) (*Job, error) {
+	var job *Job
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(jobsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrJobNotFound
+		}
+		return json.Unmarshal(data, &job)
+	})
+	return job, err
+}
+
+func (b *BoltStore) ListJobs(filter JobFilter) ([]*Job, error) {
+	var jobs []*Job
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(_, data []byte) error {
+			var job Job
+			if err := json.Unmarshal(data, &job); err != nil {
+				return err
+			}
+			if filter.matches(&job) {
+				jobs = append(jobs, &job)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return limitJobs(sortJobs(jobs), filter.Limit), nil
+}
+
+func (b *BoltStore) AppendPages(id string, pages ...*scraper.ScrapedPage) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(jobsBucket).Get([]byte(id)) == nil {
+			return ErrJobNotFound
+		}
+
+		bucket, err := tx.Bucket(pagesBucket).CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+
+		for _, page := range pages {
+			data, err := json.Marshal(page)
+			if err != nil {
+				return err
+			}
+
+			seq, _ := bucket.NextSequence()
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, seq)
+			if err := bucket.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BoltStore) GetPages(id string) ([]*scraper.ScrapedPage, error) {
+	var pages []*scraper.ScrapedPage
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(jobsBucket).Get([]byte(id)) == nil {
+			return ErrJobNotFound
+		}
+
+		bucket := tx.Bucket(pagesBucket).Bucket([]byte(id))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(_, data []byte) error {
+			var page scraper.ScrapedPage
+			if err := json.Unmarshal(data, &page); err != nil {
+				return err
+			}
+			pages = append(pages, &page)
+			return nil
+		})
+	})
+	return pages, err
+}
+
+func (b *BoltStore) SaveCheckpoint(id string, state *scraper.CrawlState) error {
+	if state == nil {
+		return b.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(checkpointsBucket).Delete([]byte(id))
+		})
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(jobsBucket).Get([]byte(id)) == nil {
+			return ErrJobNotFound
+		}
+		return tx.Bucket(checkpointsBucket).Put([]byte(id), data)
+	})
+}
+
+func (b *BoltStore) GetCheckpoint(id string) (*scraper.CrawlState, error) {
+	var state *scraper.CrawlState
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(checkpointsBucket).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &state)
+	})
+	return state, err
+}
+
+func (b *BoltStore) DeleteExpired(now time.Time) (int, error) {
+	deleted := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobsBucket)
+		pages := tx.Bucket(pagesBucket)
+		checkpoints := tx.Bucket(checkpointsBucket)
+
+		var expired [][]byte
+		err := jobs.ForEach(func(id, data []byte) error {
+			var job Job
+			if err := json.Unmarshal(data, &job); err != nil {
+				return err
+			}
+			if job.ExpiresAt != nil && job.ExpiresAt.Before(now) {
+				expired = append(expired, append([]byte(nil), id...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range expired {
+			if err := jobs.Delete(id); err != nil {
+				return err
+			}
+			if pages.Bucket(id) != nil {
+				if err := pages.DeleteBucket(id); err != nil {
+					return err
+				}
+			}
+			if err := checkpoints.Delete(id); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
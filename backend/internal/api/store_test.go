@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"website-markdown/internal/scraper"
+)
+
+// testStores returns an empty store of every kind.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bolt.Close() })
+	return map[string]Store{"memory": NewMemoryStore(), "bolt": bolt}
+}
+
+func TestStoreJobsAndPages(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for i, owner := range []string{"ci", "ci", "docs"} {
+				job := &Job{ID: string(rune('a' + i)), Owner: owner, Status: JobCompleted, CreatedAt: start.Add(time.Duration(i) * time.Hour)}
+				if err := store.SaveJob(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := store.GetJob("missing"); !errors.Is(err, ErrJobNotFound) {
+				t.Errorf("GetJob(missing) error = %v, want ErrJobNotFound", err)
+			}
+			jobs, err := store.ListJobs(JobFilter{Owner: "ci"})
+			if err != nil || len(jobs) != 2 || jobs[0].ID != "b" || jobs[1].ID != "a" {
+				t.Errorf("ListJobs(ci) = %v, %v, want b and a, newest first", jobs, err)
+			}
+			if jobs, _ := store.ListJobs(JobFilter{Limit: 1}); len(jobs) != 1 || jobs[0].ID != "c" {
+				t.Errorf("ListJobs(limit 1) = %v, want the newest job", jobs)
+			}
+
+			// Pages come back in the order they were appended
+			if err := store.AppendPages("a", &scraper.ScrapedPage{URL: "https://example.com/1"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.AppendPages("a", &scraper.ScrapedPage{URL: "https://example.com/2"}, &scraper.ScrapedPage{URL: "https://example.com/3"}); err != nil {
+				t.Fatal(err)
+			}
+			pages, err := store.GetPages("a")
+			if err != nil || len(pages) != 3 || pages[0].URL != "https://example.com/1" || pages[2].URL != "https://example.com/3" {
+				t.Errorf("GetPages() = %d pages, %v, want the 3 in order", len(pages), err)
+			}
+			if err := store.AppendPages("missing", &scraper.ScrapedPage{}); !errors.Is(err, ErrJobNotFound) {
+				t.Errorf("AppendPages(missing) error = %v, want ErrJobNotFound", err)
+			}
+
+			state := &scraper.CrawlState{Version: scraper.CRAWL_STATE_VERSION, PageCount: 3}
+			if err := store.SaveCheckpoint("a", state); err != nil {
+				t.Fatal(err)
+			}
+			if saved, err := store.GetCheckpoint("a"); err != nil || saved == nil || saved.PageCount != 3 {
+				t.Errorf("GetCheckpoint() = %+v, %v, want the saved state", saved, err)
+			}
+			if err := store.SaveCheckpoint("a", nil); err != nil {
+				t.Fatal(err)
+			}
+			if saved, err := store.GetCheckpoint("a"); err != nil || saved != nil {
+				t.Errorf("GetCheckpoint() after deleting = %+v, %v, want nil", saved, err)
+			}
+		})
+	}
+}
+
+func TestStoreDeleteExpired(t *testing.T) {
+	now := time.Now()
+	expired, later := now.Add(-time.Minute), now.Add(time.Hour)
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for id, expiresAt := range map[string]*time.Time{"expired": &expired, "later": &later, "running": nil} {
+				if err := store.SaveJob(&Job{ID: id, ExpiresAt: expiresAt}); err != nil {
+					t.Fatal(err)
+				}
+				if err := store.AppendPages(id, &scraper.ScrapedPage{URL: "https://example.com/"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deleted, err := store.DeleteExpired(now)
+			if err != nil || deleted != 1 {
+				t.Fatalf("DeleteExpired() = %d, %v, want 1", deleted, err)
+			}
+			if _, err := store.GetPages("expired"); !errors.Is(err, ErrJobNotFound) {
+				t.Errorf("GetPages() of the expired job error = %v, want ErrJobNotFound", err)
+			}
+			for _, id := range []string{"later", "running"} {
+				if _, err := store.GetJob(id); err != nil {
+					t.Errorf("GetJob(%s) error = %v, want it kept", id, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBoltStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveJob(&Job{ID: "a", Status: JobCompleted, Stats: &ScrapeStats{TotalPages: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AppendPages("a", &scraper.ScrapedPage{URL: "https://example.com/", Markdown: "# Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	job, err := store.GetJob("a")
+	if err != nil || job.Stats == nil || job.Stats.TotalPages != 1 {
+		t.Fatalf("GetJob() after reopening = %+v, %v, want the saved job", job, err)
+	}
+	if pages, err := store.GetPages("a"); err != nil || len(pages) != 1 || pages[0].Markdown != "# Hi" {
+		t.Errorf("GetPages() after reopening = %v, %v, want the saved page", pages, err)
+	}
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"website-markdown/internal/scraper"
+)
+
+const (
+	WEBHOOK_MAX_ATTEMPTS    = 5
+	WEBHOOK_INITIAL_BACKOFF = 2 * time.Second
+	WEBHOOK_TIMEOUT         = 10 * time.Second
+
+	WEBHOOK_SIGNATURE_HEADER = "X-Webhook-Signature"
+	WEBHOOK_EVENT_HEADER     = "X-Webhook-Event"
+	WEBHOOK_DELIVERY_HEADER  = "X-Webhook-Delivery"
+)
+
+// WebhookStatus records the delivery of a job's completion callback.
+type WebhookStatus struct {
+	URL       string           `json:"url"`
+	Delivered bool             `json:"delivered"`
+	Attempts  []WebhookAttempt `json:"attempts,omitempty"`
+}
+
+type WebhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Duration   string    `json:"duration"`
+}
+
+// WebhookPayload is the JSON body POSTed to the callback URL. When a secret
+// is set, the X-Webhook-Signature header carries "sha256=" followed by the
+// hex HMAC-SHA256 of the body.
+type WebhookPayload struct {
+	Event       string       `json:"event"` // job.completed, job.failed or job.cancelled
+	JobID       string       `json:"jobId"`
+	Status      JobStatus    `json:"status"`
+	URL         string       `json:"url"`
+	URLs        []string     `json:"urls,omitempty"` // all seeds, when there are several
+	Error       string       `json:"error,omitempty"`
+	Stats       *ScrapeStats `json:"stats,omitempty"`
+	ResultURL   string       `json:"resultUrl"`
+	DownloadURL string       `json:"downloadUrl"`
+	SentAt      time.Time    `json:"sentAt"`
+}
+
+// validateCallbackURL checks that a callback URL is an absolute http(s) URL.
+func validateCallbackURL(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if parsedURL.Host == "" {
+		return fmt.Errorf("host is missing")
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the signature header value for body.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyWebhook delivers the job's callback in the background, if one was
+// requested. Delivery keeps the server from shutting down until it is done.
+func (s *Server) notifyWebhook(job *Job) {
+	if job.Webhook == nil {
+		return
+	}
+
+	ctx, done := s.jobs.track()
+	go func() {
+		defer done()
+		s.deliverWebhook(ctx, job)
+	}()
+}
+
+// deliverWebhook POSTs the signed payload, retrying with exponential backoff.
+// Every attempt is recorded on the job.
+func (s *Server) deliverWebhook(ctx context.Context, job *Job) {
+	seeds := job.Request.Seeds()
+	payload := WebhookPayload{
+		Event:       "job." + string(job.Status),
+		JobID:       job.ID,
+		Status:      job.Status,
+		URL:         seeds[0],
+		Error:       job.Error,
+		Stats:       job.Stats,
+		ResultURL:   job.BaseURL + "/jobs/" + job.ID + "/pages",
+		DownloadURL: job.BaseURL + "/jobs/" + job.ID + "/download",
+		SentAt:      time.Now().UTC(),
+	}
+	if len(seeds) > 1 {
+		payload.URLs = seeds
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to encode webhook for job %s: %v\n", job.ID, err)
+		return
+	}
+
+	client := &http.Client{
+		Timeout:   WEBHOOK_TIMEOUT,
+		Transport: scraper.NewNetworkGuard(s.config.AllowHosts).Transport(),
+		// Don't let a redirect turn the POST into a GET somewhere else
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	backoff := WEBHOOK_INITIAL_BACKOFF
+	for attempt := 1; attempt <= WEBHOOK_MAX_ATTEMPTS; attempt++ {
+		result, retry := s.postWebhook(ctx, client, job, payload.Event, body, attempt)
+		// Copy on write: the stored job may be read concurrently
+		status := &WebhookStatus{
+			URL:       job.Webhook.URL,
+			Delivered: result.Error == "",
+			Attempts:  append(append([]WebhookAttempt(nil), job.Webhook.Attempts...), result),
+		}
+		job.Webhook = status
+		s.saveJob(job)
+
+		if job.Webhook.Delivered {
+			fmt.Printf("📬 Webhook for job %s delivered to %s\n", job.ID, job.Webhook.URL)
+			return
+		}
+		fmt.Printf("⚠️  Webhook attempt %d/%d for job %s failed: %s\n", attempt, WEBHOOK_MAX_ATTEMPTS, job.ID, result.Error)
+		if !retry || attempt == WEBHOOK_MAX_ATTEMPTS {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// postWebhook makes one delivery attempt and reports whether a failure is
+// worth retrying.
+func (s *Server) postWebhook(ctx context.Context, client *http.Client, job *Job, event string, body []byte, attempt int) (result WebhookAttempt, retry bool) {
+	result.At = time.Now().UTC()
+	defer func() {
+		result.Duration = time.Since(result.At).String()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result, false
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Website-Markdown-API/1.0")
+	req.Header.Set(WEBHOOK_EVENT_HEADER, event)
+	req.Header.Set(WEBHOOK_DELIVERY_HEADER, fmt.Sprintf("%s-%d", job.ID, attempt))
+	if job.Request.CallbackSecret != "" {
+		req.Header.Set(WEBHOOK_SIGNATURE_HEADER, SignWebhookPayload(job.Request.CallbackSecret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result, true
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return result, false
+	}
+
+	result.Error = resp.Status
+	retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return result, retry
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// webhookReceiver records the callbacks it gets and answers with status.
+type webhookReceiver struct {
+	mutex    sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	status   int
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mutex.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	r.mutex.Unlock()
+	w.WriteHeader(r.status)
+}
+
+func webhookServer(t *testing.T, status int) (*Server, *webhookReceiver, *Job) {
+	t.Helper()
+	receiver := &webhookReceiver{status: status}
+	target := httptest.NewServer(receiver)
+	t.Cleanup(target.Close)
+
+	config := DefaultServerConfig()
+	config.AllowHosts = []string{"127.0.0.1"}
+	server := NewServer(config, nil, nil)
+	job := &Job{
+		ID:      "job-1",
+		Status:  JobCompleted,
+		Request: ScrapeRequest{URL: "https://example.com/", CallbackURL: target.URL, CallbackSecret: "hush"},
+		BaseURL: "https://api.example.com",
+		Stats:   &ScrapeStats{TotalPages: 3},
+		Webhook: &WebhookStatus{URL: target.URL},
+	}
+	if err := server.store.SaveJob(job); err != nil {
+		t.Fatal(err)
+	}
+	return server, receiver, job
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	server, receiver, job := webhookServer(t, http.StatusNoContent)
+	server.deliverWebhook(context.Background(), job)
+
+	if len(receiver.requests) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(receiver.requests))
+	}
+	req, body := receiver.requests[0], receiver.bodies[0]
+	if got := req.Header.Get(WEBHOOK_SIGNATURE_HEADER); got != SignWebhookPayload("hush", body) {
+		t.Errorf("signature = %q, want the HMAC of the body", got)
+	}
+	if SignWebhookPayload("other", body) == SignWebhookPayload("hush", body) {
+		t.Error("the signature doesn't depend on the secret")
+	}
+	if req.Header.Get(WEBHOOK_EVENT_HEADER) != "job.completed" || req.Header.Get(WEBHOOK_DELIVERY_HEADER) != "job-1-1" {
+		t.Errorf("event and delivery headers = %q, %q", req.Header.Get(WEBHOOK_EVENT_HEADER), req.Header.Get(WEBHOOK_DELIVERY_HEADER))
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.JobID != "job-1" || payload.Stats.TotalPages != 3 || payload.DownloadURL != "https://api.example.com/jobs/job-1/download" {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	stored, err := server.store.GetJob("job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Webhook.Delivered || len(stored.Webhook.Attempts) != 1 || stored.Webhook.Attempts[0].StatusCode != http.StatusNoContent {
+		t.Errorf("stored webhook status = %+v, want one successful attempt", stored.Webhook)
+	}
+}
+
+func TestDeliverWebhookGivesUpOnClientErrors(t *testing.T) {
+	server, receiver, job := webhookServer(t, http.StatusBadRequest)
+	server.deliverWebhook(context.Background(), job)
+
+	if len(receiver.requests) != 1 {
+		t.Errorf("got %d deliveries, want no retry after a 400", len(receiver.requests))
+	}
+	if stored, _ := server.store.GetJob("job-1"); stored.Webhook.Delivered || stored.Webhook.Attempts[0].Error == "" {
+		t.Errorf("stored webhook status = %+v, want a failed attempt", stored.Webhook)
+	}
+}
+
+func TestPostWebhookRetries(t *testing.T) {
+	for status, retry := range map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusBadRequest:          false,
+		http.StatusRequestTimeout:      true,
+		http.StatusTooManyRequests:     true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusInternalServerError: true,
+	} {
+		server, _, job := webhookServer(t, status)
+		client := &http.Client{}
+		result, gotRetry := server.postWebhook(context.Background(), client, job, "job.completed", []byte("{}"), 1)
+		if gotRetry != retry || result.StatusCode != status {
+			t.Errorf("HTTP %d: retry = %t (status %d), want %t", status, gotRetry, result.StatusCode, retry)
+		}
+	}
+
+	// Unreachable receivers are retried too
+	server, _, job := webhookServer(t, http.StatusOK)
+	job.Webhook.URL = "http://127.0.0.1:1/"
+	if _, retry := server.postWebhook(context.Background(), &http.Client{}, job, "job.completed", []byte("{}"), 1); !retry {
+		t.Error("a connection error isn't retried")
+	}
+}
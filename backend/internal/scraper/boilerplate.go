@@ -0,0 +1,177 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	BOILERPLATE_KEEP   = "keep"   // leave pages as they are
+	BOILERPLATE_STRIP  = "strip"  // remove repeated blocks
+	BOILERPLATE_CHROME = "chrome" // remove them, but keep one copy as site chrome
+
+	DEFAULT_BOILERPLATE_THRESHOLD = 0.5
+	MIN_BOILERPLATE_PAGES         = 3 // fewer pages don't say what repeats
+	MIN_BOILERPLATE_WORDS         = 8 // shorter repeated blocks only go at the top or bottom of a page
+)
+
+// Boilerplate is the Markdown that repeats across the pages of a site:
+// headers, footers, navigation and the like.
+type Boilerplate struct {
+	Blocks []string // in the order they first appear
+	Pages  int      // pages they were found on at least Threshold of
+}
+
+// Markdown joins the blocks into one document, for a shared "site chrome"
+// section.
+func (b *Boilerplate) Markdown() string {
+	return strings.Join(b.Blocks, "\n\n")
+}
+
+// RemoveBoilerplate finds the Markdown blocks (paragraphs, lists or tables)
+// that appear on at least threshold of the successful pages and removes
+// them from every page where they are long or at the top or bottom of the
+// page. Headings and code blocks are never removed. It works on the final
+// Markdown, so it catches chrome that no CSS selector filtered out.
+// Afterwards the pages' tokens are recounted with tokenizer, or with
+// ApproximateTokenizer if it is nil. It returns nil if nothing repeats or
+// there are fewer than MIN_BOILERPLATE_PAGES pages.
+func RemoveBoilerplate(pages []*ScrapedPage, threshold float64, tokenizer Tokenizer) *Boilerplate {
+	var scraped []*ScrapedPage
+	for _, page := range pages {
+		if page.Error == "" {
+			scraped = append(scraped, page)
+		}
+	}
+	if len(scraped) < MIN_BOILERPLATE_PAGES {
+		return nil
+	}
+
+	// Count the pages each block is on, not how often it occurs
+	counts := make(map[string]int)
+	var order []string
+	for _, page := range scraped {
+		seen := make(map[string]bool)
+		for _, block := range splitParagraphs(page.Markdown) {
+			key := boilerplateKey(block)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if counts[key] == 0 {
+				order = append(order, key)
+			}
+			counts[key]++
+		}
+	}
+
+	minPages := int(threshold * float64(len(scraped)))
+	if minPages < 2 {
+		minPages = 2
+	}
+	repeated := make(map[string]bool)
+	boilerplate := &Boilerplate{Pages: len(scraped)}
+	for _, key := range order {
+		if counts[key] >= minPages {
+			repeated[key] = true
+		}
+	}
+	if len(repeated) == 0 {
+		return nil
+	}
+
+	blocks := make(map[string]string) // key -> block as first written
+	for _, page := range scraped {
+		paragraphs := splitParagraphs(page.Markdown)
+		chrome := boilerplateBlocks(paragraphs, repeated)
+		var kept []string
+		for i, block := range paragraphs {
+			if !chrome[i] {
+				kept = append(kept, block)
+				continue
+			}
+			key := boilerplateKey(block)
+			if _, exists := blocks[key]; !exists {
+				blocks[key] = strings.TrimSpace(block)
+				boilerplate.Blocks = append(boilerplate.Blocks, blocks[key])
+			}
+		}
+		page.Markdown = strings.TrimSpace(multipleBlankLines.ReplaceAllString(strings.Join(kept, ""), "\n\n"))
+		digestPage(page, tokenizer)
+	}
+
+	if len(boilerplate.Blocks) == 0 {
+		return nil
+	}
+	return boilerplate
+}
+
+var multipleBlankLines = regexp.MustCompile(`\n{3,}`)
+
+// boilerplateBlocks picks the repeated blocks of a page that are chrome:
+// those long enough not to be a repeated label, and those at the top or
+// bottom of the page, before or after its first or last own content.
+func boilerplateBlocks(paragraphs []string, repeated map[string]bool) map[int]bool {
+	chrome := make(map[int]bool)
+	// Blocks without text, such as rules, don't end the top or bottom run
+	edge := func(i int) bool {
+		key := boilerplateKey(paragraphs[i])
+		return repeated[key] || (key == "" && !isStructuralBlock(paragraphs[i]))
+	}
+	for i := 0; i < len(paragraphs) && edge(i); i++ {
+		chrome[i] = true
+	}
+	for i := len(paragraphs) - 1; i >= 0 && edge(i); i-- {
+		chrome[i] = true
+	}
+	for i, block := range paragraphs {
+		key := boilerplateKey(block)
+		if !repeated[key] {
+			delete(chrome, i)
+			continue
+		}
+		if len(strings.Fields(key)) >= MIN_BOILERPLATE_WORDS {
+			chrome[i] = true
+		}
+	}
+	return chrome
+}
+
+// boilerplateKey identifies a block regardless of its whitespace. Blocks
+// without any text, such as horizontal rules, and headings and code blocks,
+// which repeat across pages of the same kind, are left alone.
+func boilerplateKey(block string) string {
+	if isStructuralBlock(block) {
+		return ""
+	}
+	key := strings.Join(strings.Fields(block), " ")
+	if strings.IndexFunc(key, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	return key
+}
+
+// isStructuralBlock reports whether block is a heading, a code block or the
+// link definitions of referenced links.
+func isStructuralBlock(block string) bool {
+	trimmed := strings.TrimSpace(block)
+	first, _, _ := strings.Cut(trimmed, "\n")
+	// Link definitions belong to the [text][1] links above them
+	if headingPattern.MatchString(first) || fencePattern.MatchString(first) || linkDefinitionPattern.MatchString(first) {
+		return true
+	}
+	// Setext headings: a line underlined with === or ---
+	lines := strings.Split(trimmed, "\n")
+	return len(lines) == 2 && setextUnderlinePattern.MatchString(lines[1])
+}
+
+var setextUnderlinePattern = regexp.MustCompile(`^\s*(=+|-+)\s*$`)
+
+// digestPage updates what is derived from a page's Markdown: its content
+// hash, fingerprint and size.
+func digestPage(page *ScrapedPage, tokenizer Tokenizer) {
+	page.ContentHash = contentHash(page.Markdown)
+	page.Fingerprint = fingerprint(page.Markdown)
+	page.TextSize = MeasureText(page.Markdown, tokenizer)
+}
@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRemoveBoilerplateKeepsStructure(t *testing.T) {
+	const (
+		nav    = "[Home](/) | [Docs](/docs) | [Blog](/blog)"
+		footer = "Copyright 2024 Example Inc. All rights reserved. Built with care by the docs team."
+		label  = "See also"
+	)
+
+	var pages []*ScrapedPage
+	for i := 0; i < 4; i++ {
+		markdown := strings.Join([]string{
+			nav,
+			fmt.Sprintf("# Function f%d", i),
+			"## Parameters",
+			fmt.Sprintf("Param p%d", i),
+			label,
+			"## Returns",
+			fmt.Sprintf("ret %d", i),
+			"```go\nfunc Example() {}\n```",
+			footer,
+		}, "\n\n")
+		pages = append(pages, &ScrapedPage{URL: fmt.Sprintf("https://example.com/f%d", i), Markdown: markdown})
+	}
+
+	boilerplate := RemoveBoilerplate(pages, DEFAULT_BOILERPLATE_THRESHOLD, nil)
+	if boilerplate == nil {
+		t.Fatal("RemoveBoilerplate() = nil, want the nav and footer")
+	}
+	if got, want := boilerplate.Blocks, []string{nav, footer}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Blocks = %q, want %q", got, want)
+	}
+
+	for i, page := range pages {
+		want := strings.Join([]string{
+			fmt.Sprintf("# Function f%d", i),
+			"## Parameters",
+			fmt.Sprintf("Param p%d", i),
+			label, // short and in the middle of the page
+			"## Returns",
+			fmt.Sprintf("ret %d", i),
+			"```go\nfunc Example() {}\n```",
+		}, "\n\n")
+		if page.Markdown != want {
+			t.Errorf("page %d Markdown =\n%s\nwant\n%s", i, page.Markdown, want)
+		}
+	}
+}
+
+func TestRemoveBoilerplateNeedsEnoughPages(t *testing.T) {
+	pages := []*ScrapedPage{
+		{URL: "https://example.com/a", Markdown: "Shared footer text\n\nA"},
+		{URL: "https://example.com/b", Markdown: "Shared footer text\n\nB"},
+	}
+	if boilerplate := RemoveBoilerplate(pages, DEFAULT_BOILERPLATE_THRESHOLD, nil); boilerplate != nil {
+		t.Errorf("RemoveBoilerplate() = %v, want nil for %d pages", boilerplate, len(pages))
+	}
+}
@@ -0,0 +1,262 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const CRAWL_STATE_VERSION = 1
+
+// FrontierEntry is a URL still to be scraped.
+type FrontierEntry struct {
+	URL     string `json:"url"`
+	Seed    string `json:"seed"`
+	Depth   int    `json:"depth"`
+	Revisit bool   `json:"revisit,omitempty"` // already written, fetched again only for its links
+}
+
+// SeedScope is a seed URL and the host its crawl is limited to.
+type SeedScope struct {
+	URL  string `json:"url"`
+	Host string `json:"host"`
+}
+
+// CrawlState is a snapshot of a crawl that can be continued with Resume.
+type CrawlState struct {
+	Version        int             `json:"version"`
+	Config         ScrapingConfig  `json:"config"`
+	Seeds          []SeedScope     `json:"seeds"`
+	Frontier       []FrontierEntry `json:"frontier"`  // still to be scraped
+	Visited        []string        `json:"visited"`   // every URL already queued or scraped
+	Completed      []string        `json:"completed"` // URLs that are fully handled
+	PageCount      int             `json:"pageCount"`
+	TokenCount     int             `json:"tokenCount,omitempty"`
+	DuplicateCount int             `json:"duplicateCount"`
+	DroppedCount   int             `json:"droppedCount,omitempty"`
+	SavedAt        time.Time       `json:"savedAt"`
+
+	// The duplicate detector's memory: distinct pages and clusters so far
+	Fingerprints      []PageFingerprint  `json:"fingerprints,omitempty"`
+	DuplicateClusters []DuplicateCluster `json:"duplicateClusters,omitempty"`
+}
+
+// MarkCompleted records pages that reached the output after the last
+// checkpoint as done, so they aren't written again. Their links were lost
+// with the crawl, so the ones that may have links are queued to be fetched
+// again for them alone.
+func (state *CrawlState) MarkCompleted(pages ...*ScrapedPage) {
+	done := make(map[string]*ScrapedPage)
+	for _, page := range pages {
+		done[page.URL] = page
+	}
+	for _, completedURL := range state.Completed {
+		delete(done, completedURL)
+	}
+
+	var frontier []FrontierEntry
+	for _, entry := range state.Frontier {
+		if _, isDone := done[entry.URL]; !isDone {
+			frontier = append(frontier, entry)
+		} else if entry.Revisit {
+			// Marked by an earlier resume that didn't get to it
+			frontier = append(frontier, entry)
+			delete(done, entry.URL)
+		}
+	}
+
+	visited := make(map[string]bool)
+	for _, visitedURL := range state.Visited {
+		visited[visitedURL] = true
+	}
+	for _, page := range pages {
+		if done[page.URL] != page {
+			continue
+		}
+		delete(done, page.URL)
+
+		state.PageCount++
+		state.TokenCount += page.Tokens
+		state.rememberPage(page)
+		if !visited[page.URL] {
+			visited[page.URL] = true
+			state.Visited = append(state.Visited, page.URL)
+		}
+
+		if page.Error == "" && page.Depth < state.Config.MaxDepth {
+			frontier = append(frontier, FrontierEntry{URL: page.URL, Seed: page.Seed, Depth: page.Depth, Revisit: true})
+		} else {
+			state.Completed = append(state.Completed, page.URL)
+		}
+	}
+	state.Frontier = frontier
+}
+
+// rememberPage adds a page that the crawl state missed to the duplicate
+// detector's memory.
+func (state *CrawlState) rememberPage(page *ScrapedPage) {
+	current := PageFingerprint{URL: page.URL, ContentHash: page.ContentHash, Fingerprint: page.Fingerprint}
+	if page.DuplicateOf == "" {
+		state.Fingerprints = append(state.Fingerprints, current)
+		return
+	}
+
+	for _, seen := range state.Fingerprints {
+		if seen.URL != page.DuplicateOf {
+			continue
+		}
+		duplicate := DuplicatePage{URL: page.URL, Similarity: similarity(current, seen)}
+		for i := range state.DuplicateClusters {
+			if state.DuplicateClusters[i].URL == seen.URL {
+				state.DuplicateClusters[i].Duplicates = append(state.DuplicateClusters[i].Duplicates, duplicate)
+				return
+			}
+		}
+		state.DuplicateClusters = append(state.DuplicateClusters, DuplicateCluster{URL: seen.URL, Duplicates: []DuplicatePage{duplicate}})
+		return
+	}
+}
+
+// SaveCrawlState writes state to path atomically, so a crash mid-write
+// leaves the previous checkpoint intact.
+func SaveCrawlState(path string, state *CrawlState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// SeedURLs returns the crawl's seed URLs in their original order.
+func (state *CrawlState) SeedURLs() []string {
+	var urls []string
+	for _, seed := range state.Seeds {
+		urls = append(urls, seed.URL)
+	}
+	return urls
+}
+
+func LoadCrawlState(path string) (*CrawlState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var state CrawlState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid crawl state: %v", err)
+	}
+	if state.Version != CRAWL_STATE_VERSION {
+		return nil, fmt.Errorf("unsupported crawl state version %d", state.Version)
+	}
+	return &state, nil
+}
+
+// saveCheckpoint hands the current state to the Checkpoint hook. inFlight
+// are the pages being scraped, which are saved as still to do. Unless force
+// is set, it does nothing until CheckpointInterval has passed since the last
+// checkpoint.
+func (s *Scraper) saveCheckpoint(inFlight map[string]FrontierEntry, force bool) {
+	if s.config.Checkpoint == nil {
+		return
+	}
+	if !force && time.Since(s.lastCheckpoint) < s.config.CheckpointInterval {
+		return
+	}
+	s.lastCheckpoint = time.Now()
+
+	state := &CrawlState{
+		Version:        CRAWL_STATE_VERSION,
+		Config:         s.config,
+		Frontier:       s.frontier.Entries(),
+		Completed:      append([]string(nil), s.completed...),
+		PageCount:      s.pageCount,
+		TokenCount:     s.tokenCount,
+		DuplicateCount: s.duplicateCount,
+		DroppedCount:   s.droppedCount,
+		SavedAt:        time.Now().UTC(),
+	}
+	if s.dedupe != nil {
+		state.Fingerprints = append([]PageFingerprint(nil), s.dedupe.pages...)
+		state.DuplicateClusters = s.DuplicateClusters()
+	}
+	for _, seed := range s.seeds {
+		state.Seeds = append(state.Seeds, SeedScope{URL: seed, Host: s.seedHosts[seed]})
+	}
+	for _, entry := range inFlight {
+		state.Frontier = append(state.Frontier, entry)
+	}
+
+	s.visitedMutex.RLock()
+	for visitedURL := range s.visited {
+		state.Visited = append(state.Visited, visitedURL)
+	}
+	s.visitedMutex.RUnlock()
+
+	if err := s.config.Checkpoint(state); err != nil {
+		s.logf("⚠️  Failed to save checkpoint: %v\n", err)
+	}
+}
+
+// Resume continues a crawl from a checkpoint, passing the remaining pages to
+// sink. Pages completed before the checkpoint are not fetched again. The
+// scraper should be built from state.Config, whose Order and Prefer rebuild
+// the crawl's Ordering.
+func (s *Scraper) Resume(ctx context.Context, state *CrawlState, sink PageSink) error {
+	if state.Version != CRAWL_STATE_VERSION {
+		return fmt.Errorf("unsupported crawl state version %d", state.Version)
+	}
+
+	for _, seed := range state.Seeds {
+		s.addSeed(seed.URL, seed.Host)
+	}
+	for _, visitedURL := range state.Visited {
+		s.visited[visitedURL] = true
+	}
+	s.completed = append(s.completed, state.Completed...)
+	s.pageCount = state.PageCount
+	s.tokenCount = state.TokenCount
+	s.duplicateCount = state.DuplicateCount
+	s.droppedCount = state.DroppedCount
+	if s.dedupe != nil {
+		s.dedupe.restore(state.Fingerprints, state.DuplicateClusters)
+	}
+
+	s.prepareOrdering(ctx)
+	queued := make(map[string]bool)
+	for _, completedURL := range s.completed {
+		queued[completedURL] = true
+	}
+	for _, entry := range state.Frontier {
+		if queued[entry.URL] {
+			continue
+		}
+		queued[entry.URL] = true
+		s.visited[entry.URL] = true
+		s.frontier.Push(entry)
+	}
+
+	s.logf("⏯️  Resuming crawl (%d pages done, %d queued)\n", s.pageCount, s.frontier.Len())
+	if s.frontier.Len() == 0 {
+		s.logf("✅ Nothing left to scrape\n")
+		return nil
+	}
+	return s.run(ctx, sink)
+}
@@ -0,0 +1,111 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// linkedSite serves pages that link to each other as given by links.
+func linkedSite(links map[string][]string) MemoryFetcher {
+	site := make(MemoryFetcher)
+	for path, targets := range links {
+		var body strings.Builder
+		fmt.Fprintf(&body, "<html><head><title>Page %s</title></head><body><h1>Page %s</h1>", path, path)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(&body, "<p>Paragraph %d of page %s, long enough to count as real content for the crawler.</p>", i, path)
+		}
+		for _, target := range targets {
+			fmt.Fprintf(&body, `<p><a href="%s">%s</a></p>`, target, target)
+		}
+		body.WriteString("</body></html>")
+		site["https://example.com"+path] = body.String()
+	}
+	return site
+}
+
+type collectSink struct {
+	pages []*ScrapedPage
+	limit int // fail once this many pages were written, if set
+}
+
+var errSinkFull = errors.New("sink full")
+
+func (c *collectSink) WritePage(page *ScrapedPage) error {
+	if c.limit > 0 && len(c.pages) >= c.limit {
+		return errSinkFull
+	}
+	c.pages = append(c.pages, page)
+	return nil
+}
+
+func (c *collectSink) urls() []string {
+	var urls []string
+	for _, page := range c.pages {
+		urls = append(urls, page.URL)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestResumeAfterCrashBetweenCheckpoints(t *testing.T) {
+	site := linkedSite(map[string][]string{
+		"/":    {"/a", "/b"},
+		"/a":   {"/a1", "/a2"},
+		"/b":   {"/b1"},
+		"/a1":  {"/a1x"},
+		"/a2":  nil,
+		"/b1":  nil,
+		"/a1x": nil,
+	})
+	config := func() *ScrapingConfig {
+		return &ScrapingConfig{MaxDepth: 3, Concurrency: 1, Fetcher: site, Log: io.Discard}
+	}
+
+	full := &collectSink{}
+	if err := NewScraper(config()).ScrapeTo(context.Background(), full, "https://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first checkpoint is saved before the crawl dies three pages
+	// in; the last one, saved on the way out, is lost with the process
+	var saved []byte
+	crashing := config()
+	crashing.CheckpointInterval = time.Hour
+	crashing.Checkpoint = func(state *CrawlState) error {
+		if saved == nil {
+			saved, _ = json.Marshal(state)
+		}
+		return nil
+	}
+	written := &collectSink{limit: 3}
+	err := NewScraper(crashing).ScrapeTo(context.Background(), written, "https://example.com/")
+	if !errors.Is(err, errSinkFull) {
+		t.Fatalf("ScrapeTo() error = %v, want the sink to fail", err)
+	}
+
+	var state CrawlState
+	if err := json.Unmarshal(saved, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Completed) >= len(written.pages) {
+		t.Fatalf("checkpoint covers %d of %d written pages, the crash doesn't fall between checkpoints", len(state.Completed), len(written.pages))
+	}
+	state.MarkCompleted(written.pages...)
+
+	rest := &collectSink{}
+	if err := NewScraper(config()).Resume(context.Background(), &state, rest); err != nil {
+		t.Fatal(err)
+	}
+
+	rest.pages = append(rest.pages, written.pages...)
+	if got, want := rest.urls(), full.urls(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("crashed and resumed crawl wrote\n%v\nwant the pages of an uninterrupted one, once each:\n%v", got, want)
+	}
+}
@@ -0,0 +1,359 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	CHUNK_UNIT_TOKENS = "tokens"
+	CHUNK_UNIT_CHARS  = "chars"
+
+	DEFAULT_CHUNK_SIZE    = 512 // tokens
+	DEFAULT_CHUNK_OVERLAP = 64  // tokens, at most a quarter of the chunk size
+)
+
+// Chunk is a piece of a page small enough to embed or retrieve on its own.
+type Chunk struct {
+	ID         string   `json:"id"` // url#chunk-index
+	URL        string   `json:"url"`
+	Anchor     string   `json:"anchor,omitempty"` // slug of the section heading
+	Title      string   `json:"title"`
+	Breadcrumb []string `json:"breadcrumb,omitempty"` // headings above the chunk, outermost first
+	Index      int      `json:"index"`
+	Text       string   `json:"text"`
+	Size       int      `json:"size"` // in the options' Unit
+	Seed       string   `json:"seed,omitempty"`
+}
+
+// ChunkOptions sets the chunk budget. Size and Overlap are counted in Unit;
+// a zero Size and a nil Overlap get the defaults, and an Overlap of 0 turns
+// overlap off.
+type ChunkOptions struct {
+	Size    int    `json:"size,omitempty"`
+	Overlap *int   `json:"overlap,omitempty"`
+	Unit    string `json:"unit,omitempty"` // CHUNK_UNIT_TOKENS (default) or CHUNK_UNIT_CHARS
+
+	// Tokenizer counts tokens. Defaults to ApproximateTokenizer.
+	Tokenizer Tokenizer `json:"-"`
+}
+
+// Validate fills in defaults and checks the options.
+func (o *ChunkOptions) Validate() error {
+	if o.Unit == "" {
+		o.Unit = CHUNK_UNIT_TOKENS
+	}
+	if o.Unit != CHUNK_UNIT_TOKENS && o.Unit != CHUNK_UNIT_CHARS {
+		return fmt.Errorf("unknown chunk unit %q: use tokens or chars", o.Unit)
+	}
+	scale := 1
+	if o.Unit == CHUNK_UNIT_CHARS {
+		scale = 4 // about 4 characters per token
+	}
+	if o.Size <= 0 {
+		o.Size = DEFAULT_CHUNK_SIZE * scale
+	}
+	if o.Overlap == nil {
+		overlap := min(DEFAULT_CHUNK_OVERLAP*scale, o.Size/4)
+		o.Overlap = &overlap
+	}
+	if *o.Overlap < 0 || *o.Overlap >= o.Size {
+		return fmt.Errorf("chunk overlap must be at least 0 and less than the chunk size (%d)", o.Size)
+	}
+	return nil
+}
+
+func (o ChunkOptions) measure(text string) int {
+	if o.Unit == CHUNK_UNIT_CHARS {
+		return utf8.RuneCountInString(text)
+	}
+	if o.Tokenizer == nil {
+		return ApproximateTokenizer.CountTokens(text)
+	}
+	return o.Tokenizer.CountTokens(text)
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	fencePattern   = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// section is the text under one heading, up to the next heading.
+type section struct {
+	level      int // 0 for text before the first heading
+	breadcrumb []string
+	anchor     string
+	text       string
+}
+
+// ChunkPage splits a page's Markdown along its headings (ATX or setext)
+// into chunks of at most options.Size, with referenced links inlined. Small
+// subsections are kept together with their parent section; sections that
+// are too big are split between paragraphs (and, if need be, lines and
+// words), with options.Overlap carried over from the previous chunk. It
+// fails if the options are invalid.
+func ChunkPage(page *ScrapedPage, options ChunkOptions) ([]Chunk, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	var chunks []Chunk
+	add := func(sec section, text string) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+		chunks = append(chunks, Chunk{
+			ID:         fmt.Sprintf("%s#chunk-%d", page.URL, len(chunks)),
+			URL:        page.URL,
+			Anchor:     sec.anchor,
+			Title:      page.Title,
+			Breadcrumb: sec.breadcrumb,
+			Index:      len(chunks),
+			Text:       text,
+			Size:       options.measure(text),
+			Seed:       page.Seed,
+		})
+	}
+
+	// current collects a section and the subsections that fit with it
+	var current *section
+	flush := func() {
+		if current != nil {
+			add(*current, current.text)
+			current = nil
+		}
+	}
+
+	for _, sec := range splitSections(InlineLinkReferences(page.Markdown)) {
+		sec := sec
+		if current != nil && current.level > 0 && sec.level > current.level &&
+			options.measure(current.text+"\n\n"+sec.text) <= options.Size {
+			current.text += "\n\n" + sec.text
+			continue
+		}
+		flush()
+
+		if options.measure(sec.text) <= options.Size {
+			current = &sec
+			continue
+		}
+		for _, piece := range splitToBudget(sec.text, options) {
+			add(sec, piece)
+		}
+	}
+	flush()
+
+	return chunks, nil
+}
+
+// splitSections cuts markdown at its ATX and setext headings, ignoring
+// lines that only look like headings inside code fences.
+func splitSections(markdown string) []section {
+	var sections []section
+	var stack []string // heading texts by level
+	var levels []int
+	anchors := make(map[string]int)
+
+	current := section{}
+	var body strings.Builder
+	inFence := false
+
+	finish := func() {
+		current.text = strings.TrimSpace(body.String())
+		if current.text != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	lines := strings.Split(markdown, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		if inFence {
+			body.WriteString(line + "\n")
+			continue
+		}
+
+		var level int
+		var title string
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			level, title = len(match[1]), strings.TrimSpace(match[2])
+		} else if isSetextHeading(lines, i) {
+			level, title = 1, strings.TrimSpace(line)
+			if strings.Contains(lines[i+1], "-") {
+				level = 2
+			}
+			line += "\n" + lines[i+1]
+			i++
+		} else {
+			body.WriteString(line + "\n")
+			continue
+		}
+
+		finish()
+
+		// Pop headings at the same or a deeper level
+		for len(levels) > 0 && levels[len(levels)-1] >= level {
+			levels = levels[:len(levels)-1]
+			stack = stack[:len(stack)-1]
+		}
+		levels = append(levels, level)
+		stack = append(stack, title)
+
+		current = section{
+			level:      level,
+			breadcrumb: append([]string(nil), stack...),
+			anchor:     uniqueAnchor(headingAnchor(title), anchors),
+		}
+		body.WriteString(line + "\n")
+	}
+	finish()
+
+	return sections
+}
+
+// isSetextHeading reports whether lines[i] is the text of a setext heading:
+// one line after a blank one, underlined with === or ---. The converter
+// writes headings on their own, so a longer paragraph above --- is left
+// alone.
+func isSetextHeading(lines []string, i int) bool {
+	if i+1 >= len(lines) || strings.TrimSpace(lines[i]) == "" || !setextUnderlinePattern.MatchString(lines[i+1]) {
+		return false
+	}
+	return i == 0 || strings.TrimSpace(lines[i-1]) == ""
+}
+
+// headingAnchor turns a heading into the slug GitHub-style renderers give
+// it: lowercase, punctuation dropped and spaces as dashes.
+func headingAnchor(heading string) string {
+	// Links in headings anchor on their text
+	heading = markdownLinkPattern.ReplaceAllString(heading, "$1")
+
+	var slug strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			slug.WriteRune(r)
+		case r == ' ':
+			slug.WriteRune('-')
+		}
+	}
+	return slug.String()
+}
+
+var markdownLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// uniqueAnchor numbers repeated anchors the way renderers do: intro,
+// intro-1, intro-2.
+func uniqueAnchor(anchor string, seen map[string]int) string {
+	count := seen[anchor]
+	seen[anchor] = count + 1
+	if count == 0 {
+		return anchor
+	}
+	return fmt.Sprintf("%s-%d", anchor, count)
+}
+
+// splitToBudget splits text into pieces of at most options.Size, preferring
+// paragraph, then line, then word boundaries. Each piece after the first
+// starts with about options.Overlap of the text before it. options must have
+// been validated.
+func splitToBudget(text string, options ChunkOptions) []string {
+	var pieces []string
+	var current []string // units of the piece being built
+	currentSize := 0
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		pieces = append(pieces, strings.Join(current, ""))
+
+		// Start the next piece with the tail of this one
+		var overlap []string
+		overlapSize := 0
+		for i := len(current) - 1; i >= 0 && *options.Overlap > 0; i-- {
+			size := options.measure(current[i])
+			if overlapSize+size > *options.Overlap {
+				break
+			}
+			overlap = append([]string{current[i]}, overlap...)
+			overlapSize += size
+		}
+		current, currentSize = overlap, overlapSize
+	}
+
+	for _, unit := range splitUnits(text, options) {
+		size := options.measure(unit)
+		if currentSize+size > options.Size {
+			flush()
+			// Drop the overlap if it leaves no room for the next unit
+			if currentSize+size > options.Size {
+				current, currentSize = nil, 0
+			}
+		}
+		current = append(current, unit)
+		currentSize += size
+	}
+	if currentSize > 0 {
+		pieces = append(pieces, strings.Join(current, ""))
+	}
+	return pieces
+}
+
+// splitUnits breaks text into pieces that each fit the budget: paragraphs
+// (code blocks stay whole) where possible, else lines, else words. Joining
+// the units gives back the text.
+func splitUnits(text string, options ChunkOptions) []string {
+	var units []string
+	for _, paragraph := range splitParagraphs(text) {
+		if options.measure(paragraph) <= options.Size {
+			units = append(units, paragraph)
+			continue
+		}
+		for _, line := range strings.SplitAfter(paragraph, "\n") {
+			if options.measure(line) <= options.Size {
+				units = append(units, line)
+				continue
+			}
+			for _, word := range strings.SplitAfter(line, " ") {
+				units = append(units, word)
+			}
+		}
+	}
+	return units
+}
+
+// splitParagraphs splits text at blank lines outside code fences. Each
+// paragraph keeps its trailing newlines.
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	var current strings.Builder
+	inFence := false
+
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		current.WriteString(line)
+
+		blank := strings.TrimSpace(line) == ""
+		nextBlank := i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == ""
+		if blank && !inFence && !nextBlank && current.Len() > 0 {
+			paragraphs = append(paragraphs, current.String())
+			current.Reset()
+		}
+	}
+	if current.Len() > 0 {
+		paragraphs = append(paragraphs, current.String())
+	}
+	return paragraphs
+}
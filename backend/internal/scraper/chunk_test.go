@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChunkPageHeadingAndLinkStyles(t *testing.T) {
+	html := `<h1>Guide</h1><p>Read <a href="https://example.com/a">the intro</a> first.</p>` +
+		`<h2>Install</h2><p>Get it from <a href="https://example.com/dl" title="Downloads">downloads</a>.</p>`
+
+	for _, options := range []ConverterOptions{
+		{},
+		{HeadingStyle: HEADING_SETEXT, LinkStyle: LINK_REFERENCED},
+	} {
+		t.Run(options.HeadingStyle+"/"+options.LinkStyle, func(t *testing.T) {
+			if err := options.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			markdown, err := newConverter(options).ConvertString(html)
+			if err != nil {
+				t.Fatal(err)
+			}
+			page := &ScrapedPage{URL: "https://example.com/guide", Title: "Guide", Markdown: markdown}
+
+			// Sections too big to share a chunk
+			chunks, err := ChunkPage(page, ChunkOptions{Size: 80, Unit: CHUNK_UNIT_CHARS})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunks) != 2 {
+				t.Fatalf("got %d chunks, want 2: %+v", len(chunks), chunks)
+			}
+			if got := strings.Join(chunks[1].Breadcrumb, " > "); got != "Guide > Install" {
+				t.Errorf("breadcrumb = %q, want Guide > Install", got)
+			}
+			if chunks[1].Anchor != "install" {
+				t.Errorf("anchor = %q, want install", chunks[1].Anchor)
+			}
+			if !strings.Contains(chunks[0].Text, "[the intro](https://example.com/a)") {
+				t.Errorf("first chunk lost its link: %q", chunks[0].Text)
+			}
+			if !strings.Contains(chunks[1].Text, `[downloads](https://example.com/dl "Downloads")`) {
+				t.Errorf("second chunk lost its link: %q", chunks[1].Text)
+			}
+		})
+	}
+}
+
+func TestChunkOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ChunkOptions
+		size    int
+		overlap int
+		wantErr bool
+	}{
+		{"defaults", ChunkOptions{}, DEFAULT_CHUNK_SIZE, DEFAULT_CHUNK_OVERLAP, false},
+		{"chars", ChunkOptions{Unit: CHUNK_UNIT_CHARS}, DEFAULT_CHUNK_SIZE * 4, DEFAULT_CHUNK_OVERLAP * 4, false},
+		{"small size", ChunkOptions{Size: 100}, 100, 25, false},
+		{"no overlap", ChunkOptions{Overlap: intPointer(0)}, DEFAULT_CHUNK_SIZE, 0, false},
+		{"overlap as big as the size", ChunkOptions{Size: 100, Overlap: intPointer(100)}, 0, 0, true},
+		{"negative overlap", ChunkOptions{Overlap: intPointer(-1)}, 0, 0, true},
+		{"unknown unit", ChunkOptions{Unit: "words"}, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := test.options
+			err := options.Validate()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Validate() error = %v, want error %t", err, test.wantErr)
+			}
+			if err == nil && (options.Size != test.size || *options.Overlap != test.overlap) {
+				t.Errorf("Validate() = size %d, overlap %d, want %d, %d", options.Size, *options.Overlap, test.size, test.overlap)
+			}
+		})
+	}
+
+	if _, err := ChunkPage(&ScrapedPage{Markdown: "text"}, ChunkOptions{Unit: "words"}); err == nil {
+		t.Error("ChunkPage() with invalid options succeeded")
+	}
+}
+
+func TestChunkOptionsOverlapJSON(t *testing.T) {
+	for body, want := range map[string]int{`{}`: DEFAULT_CHUNK_OVERLAP, `{"overlap": 0}`: 0, `{"overlap": 32}`: 32} {
+		var options ChunkOptions
+		if err := json.Unmarshal([]byte(body), &options); err != nil {
+			t.Fatal(err)
+		}
+		if err := options.Validate(); err != nil || *options.Overlap != want {
+			t.Errorf("%s: overlap = %d, %v, want %d", body, *options.Overlap, err, want)
+		}
+	}
+}
+
+func TestChunkPageBudgetAndOverlap(t *testing.T) {
+	var paragraphs []string
+	for i := 0; i < 12; i++ {
+		paragraphs = append(paragraphs, fmt.Sprintf("Paragraph %02d has a few words.", i))
+	}
+	page := &ScrapedPage{URL: "https://example.com/", Markdown: "# Long\n\n" + strings.Join(paragraphs, "\n\n")}
+
+	for _, overlap := range []int{0, 40} {
+		options := ChunkOptions{Size: 100, Overlap: &overlap, Unit: CHUNK_UNIT_CHARS}
+		chunks, err := ChunkPage(page, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) < 3 {
+			t.Fatalf("overlap %d: got %d chunks, want the section split", overlap, len(chunks))
+		}
+
+		for i, chunk := range chunks {
+			if chunk.Size > options.Size {
+				t.Errorf("overlap %d: chunk %d has size %d, over the budget of %d", overlap, i, chunk.Size, options.Size)
+			}
+			if chunk.Index != i || chunk.ID != fmt.Sprintf("https://example.com/#chunk-%d", i) {
+				t.Errorf("overlap %d: chunk %d has index %d and ID %s", overlap, i, chunk.Index, chunk.ID)
+			}
+			if i == 0 {
+				continue
+			}
+			// The last paragraph of a chunk starts the next one only with overlap
+			previous := splitParagraphs(chunks[i-1].Text)
+			last := strings.TrimSpace(previous[len(previous)-1])
+			if carried := strings.HasPrefix(chunk.Text, last); carried != (overlap > 0) {
+				t.Errorf("overlap %d: chunk %d starts with %q, carried over = %t", overlap, i, chunk.Text, carried)
+			}
+		}
+	}
+}
+
+func intPointer(value int) *int {
+	return &value
+}
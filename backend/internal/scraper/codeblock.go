@@ -0,0 +1,244 @@
+package scraper
+
+import (
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// Copy buttons and the like, whatever their class
+	codeChromeTags = "button, select, clipboard-copy, [role=button]"
+
+	MAX_CODE_WRAPPER_DEPTH = 3 // ancestors of a <pre> searched for its language and chrome
+)
+
+// Class names of line number gutters (Pygments, Chroma, Prism, Docusaurus,
+// VitePress and the like) and of copy buttons, language labels and
+// toolbars, lowercased and without CSS module suffixes (copyButton_x7Yz).
+// They are matched whole, as highlighters give code tokens arbitrary names.
+var codeChromeClasses = map[string]bool{
+	"line-numbers": true, "line-numbers-rows": true, "line-numbers-wrapper": true, "line-number": true,
+	"linenumber": true, "linenumbers": true, "codelinenumber": true, "linenos": true, "linenodiv": true,
+	"ln": true, "lnt": true, "lineno": true, "gutter": true,
+
+	"copy": true, "copy-button": true, "copybutton": true, "copy-code-button": true, "copy-code": true,
+	"copybtn": true, "btn-copy": true, "code-copy": true, "copy-to-clipboard": true, "clipboard": true,
+	"clipboard-copy": true, "toolbar": true, "toolbar-item": true,
+	"lang": true, "code-lang": true, "language-label": true,
+}
+
+// Languages that mean "no highlighting"
+var plainCodeLanguages = map[string]bool{
+	"none": true, "nohighlight": true, "plain": true, "plaintext": true, "default": true, "hljs": true,
+}
+
+// normalizeCodeBlocks rewrites the code blocks of doc as plain
+// <pre><code class="language-x"> elements before conversion: it reads the
+// language from highlighter markup, drops line number gutters, copy
+// buttons and labels, and keeps the code's line breaks.
+func normalizeCodeBlocks(doc *goquery.Document) {
+	// Gutters laid out as a table: | 1 2 3 | code |
+	doc.Find("table").Each(func(_ int, table *goquery.Selection) {
+		if table.Find("tr").Length() != 1 || table.Find("table").Length() > 0 {
+			return
+		}
+		var code, gutter *goquery.Selection
+		table.Find("td").Each(func(_ int, cell *goquery.Selection) {
+			switch {
+			case isLineNumbers(cell.Text()):
+				gutter = cell
+			case cell.Find("pre").Length() > 0:
+				code = cell.Find("pre").First()
+			}
+		})
+		if code != nil && gutter != nil {
+			table.ReplaceWithSelection(code)
+		}
+	})
+
+	doc.Find("pre").Each(func(_ int, pre *goquery.Selection) {
+		if pre.ParentsFiltered("pre").Length() > 0 {
+			return
+		}
+
+		language := codeLanguage(pre.Find("code").First())
+		if language == "" {
+			language = codeLanguage(pre)
+		}
+		parent := pre.Parent()
+		for depth := 0; depth < MAX_CODE_WRAPPER_DEPTH && parent.Length() > 0; depth++ {
+			// Stop at the page's layout, a group of several blocks or
+			// anything with prose around the block
+			if parent.Is("body, main, article, section") || parent.Find("pre").Length() > 1 ||
+				parent.Find("p, li, h1, h2, h3, h4, h5, h6, table, img").FilterFunction(outsideCode).Length() > 0 {
+				break
+			}
+			if language == "" {
+				language = codeLanguage(parent)
+			}
+			// Screen readers skip gutters and labels, but inside the code
+			// aria-hidden may hide anything
+			parent.Find("*").FilterFunction(func(i int, s *goquery.Selection) bool {
+				return outsideCode(i, s) && s.Find("pre").Length() == 0 &&
+					(isCodeChrome(s) || s.AttrOr("aria-hidden", "") == "true")
+			}).Remove()
+			parent = parent.Parent()
+		}
+
+		pre.Find("*").FilterFunction(func(_ int, s *goquery.Selection) bool {
+			return isCodeChrome(s)
+		}).Remove()
+		code := strings.Trim(codeText(pre), "\n")
+
+		class := ""
+		if language != "" {
+			class = ` class="language-` + html.EscapeString(language) + `"`
+		}
+		pre.ReplaceWithHtml("<pre><code" + class + ">" + html.EscapeString(code) + "</code></pre>")
+	})
+}
+
+func outsideCode(_ int, element *goquery.Selection) bool {
+	return element.Closest("pre").Length() == 0
+}
+
+// isCodeChrome reports whether element is a gutter, button or label of a
+// code block rather than code.
+func isCodeChrome(element *goquery.Selection) bool {
+	if element.Is(codeChromeTags) {
+		return true
+	}
+	for _, class := range strings.Fields(element.AttrOr("class", "")) {
+		if name, _, found := strings.Cut(class, "_"); found && name != "" {
+			class = name
+		}
+		if codeChromeClasses[strings.ToLower(class)] {
+			return true
+		}
+	}
+	return false
+}
+
+// codeLanguage reads the language of a code block from one of its elements:
+// data-lang, or a class such as language-go, lang-go,
+// highlight-source-python, "sourceCode python" or "brush: js".
+func codeLanguage(element *goquery.Selection) string {
+	for _, attr := range []string{"data-lang", "data-language"} {
+		if value := cleanCodeLanguage(element.AttrOr(attr, "")); value != "" {
+			return value
+		}
+	}
+
+	classes := strings.Fields(element.AttrOr("class", ""))
+	for i, class := range classes {
+		var language string
+		switch {
+		case strings.HasPrefix(class, "language-"):
+			language = strings.TrimPrefix(class, "language-")
+		case strings.HasPrefix(class, "lang-"):
+			language = strings.TrimPrefix(class, "lang-")
+		case strings.HasPrefix(class, "highlight-source-"):
+			// GitHub: highlight-source-python
+			language = strings.TrimPrefix(class, "highlight-source-")
+		case strings.HasPrefix(class, "highlight-text-"):
+			// GitHub: highlight-text-html-basic
+			language, _, _ = strings.Cut(strings.TrimPrefix(class, "highlight-text-"), "-")
+		case strings.HasPrefix(class, "highlight-") && slices.Contains(classes, "notranslate"):
+			// Sphinx: highlight-python notranslate (other highlight-* classes
+			// such as highlight-lines aren't languages)
+			language = strings.TrimPrefix(class, "highlight-")
+		case (class == "sourceCode" || class == "brush:") && i+1 < len(classes):
+			// Pandoc: sourceCode python; SyntaxHighlighter: brush: js;
+			language = classes[i+1]
+		}
+		if language = cleanCodeLanguage(language); language != "" {
+			return language
+		}
+	}
+
+	// highlight.js without a prefix: class="go hljs"
+	if len(classes) == 2 && (classes[0] == "hljs" || classes[1] == "hljs") {
+		for _, class := range classes {
+			if language := cleanCodeLanguage(class); language != "" {
+				return language
+			}
+		}
+	}
+	return ""
+}
+
+func cleanCodeLanguage(language string) string {
+	language = strings.ToLower(strings.Trim(language, " ;:"))
+	if plainCodeLanguages[language] || strings.ContainsAny(language, " `{}") {
+		return ""
+	}
+	return language
+}
+
+// codeText returns the text of a code block with a line break after each
+// <br> and each line element (highlighters often wrap every line in one,
+// with or without a newline between them).
+func codeText(pre *goquery.Selection) string {
+	var text strings.Builder
+	pendingBreak := false
+	breakLine := func() {
+		if text.Len() > 0 && !strings.HasSuffix(text.String(), "\n") {
+			text.WriteString("\n")
+		}
+		pendingBreak = false
+	}
+
+	var walk func(*goquery.Selection)
+	walk = func(nodes *goquery.Selection) {
+		nodes.Each(func(_ int, node *goquery.Selection) {
+			switch name := goquery.NodeName(node); {
+			case name == "#text":
+				content := node.Text()
+				if pendingBreak && !strings.HasPrefix(content, "\n") {
+					breakLine()
+				}
+				pendingBreak = false
+				text.WriteString(content)
+			case name == "br":
+				text.WriteString("\n")
+				pendingBreak = false
+			case isCodeLine(node):
+				if pendingBreak {
+					breakLine()
+				}
+				walk(node.Contents())
+				pendingBreak = true
+			default:
+				walk(node.Contents())
+			}
+		})
+	}
+	walk(pre.Contents())
+	return text.String()
+}
+
+// isCodeLine reports whether element holds one line of code.
+func isCodeLine(element *goquery.Selection) bool {
+	if element.Is("div, p, li") {
+		return true
+	}
+	for _, class := range strings.Fields(element.AttrOr("class", "")) {
+		switch class {
+		case "line", "token-line", "code-line", "ec-line", "highlight-line":
+			return true
+		}
+	}
+	return false
+}
+
+// isLineNumbers reports whether text is only line numbers.
+func isLineNumbers(text string) bool {
+	text = strings.TrimSpace(text)
+	return text != "" && strings.IndexFunc(text, func(r rune) bool {
+		return !unicode.IsDigit(r) && !unicode.IsSpace(r)
+	}) < 0
+}
@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestNormalizeCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		language string
+		code     string
+	}{
+		{
+			name:     "token classes that look like chrome",
+			html:     `<pre><code class="language-js">const <span class="token copyright-notice">x</span> = <span class="ln-value">1</span>;</code></pre>`,
+			language: "js",
+			code:     "const x = 1;",
+		},
+		{
+			name: "aria-hidden inside the code",
+			html: `<pre><code>a <span aria-hidden="true">b</span> c</code></pre>`,
+			code: "a b c",
+		},
+		{
+			name: "gutter, copy button and label around the code",
+			html: `<div class="highlight-python notranslate"><div class="code-block-header"><span class="lang">python</span>` +
+				`<button class="copybtn">Copy</button></div><pre><span class="ln">1</span>x = 1` + "\n" +
+				`<span class="ln">2</span>y = 2</pre><span aria-hidden="true">⧉</span></div>`,
+			language: "python",
+			code:     "x = 1\ny = 2",
+		},
+		{
+			name: "CSS module class names",
+			html: `<div class="codeBlock_a1b2"><pre><code class="language-go">go run .</code></pre>` +
+				`<div class="copyButton_x7Yz">Copy</div></div>`,
+			language: "go",
+			code:     "go run .",
+		},
+		{
+			name: "highlight-* that isn't Sphinx",
+			html: `<div class="highlight-lines"><pre>plain text</pre></div>`,
+			code: "plain text",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><body><main>" + test.html + "</main></body></html>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			normalizeCodeBlocks(doc)
+
+			code := doc.Find("pre > code")
+			if got := code.Text(); got != test.code {
+				t.Errorf("code = %q, want %q", got, test.code)
+			}
+			if got := strings.TrimPrefix(code.AttrOr("class", ""), "language-"); got != test.language {
+				t.Errorf("language = %q, want %q", got, test.language)
+			}
+			if got := strings.TrimSpace(doc.Find("main").Text()); got != test.code {
+				t.Errorf("page text = %q, want only the code", got)
+			}
+		})
+	}
+}
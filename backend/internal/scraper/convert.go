@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ConvertHTML converts an HTML document to markdown using the same
+// extraction and cleaning as a crawl, without following any links.
+// pageURL is optional and only used as the page's URL and title fallback.
+func (s *Scraper) ConvertHTML(r io.Reader, pageURL string) (*ScrapedPage, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to parse HTML: %v", err)
+	}
+
+	page := &ScrapedPage{URL: pageURL}
+	if err := s.convertDocument(doc, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ConvertURL fetches a single page and converts it to markdown. Unlike a
+// crawl it does no link discovery and keeps pages with minimal content.
+func (s *Scraper) ConvertURL(ctx context.Context, pageURL string) (*ScrapedPage, error) {
+	if s.guard != nil {
+		if err := s.guard.CheckURL(ctx, pageURL); err != nil {
+			return nil, err
+		}
+	}
+
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request: %v", err)
+	}
+
+	// Wait for our turn on this host
+	if err := s.polite.acquire(ctx, parsedURL.Host); err != nil {
+		return nil, err
+	}
+	doc, err := s.fetchDocument(ctx, pageURL)
+	s.polite.release()
+	if err != nil {
+		return nil, err
+	}
+
+	fetchedAt := time.Now().UTC()
+	page := &ScrapedPage{URL: pageURL, FetchedAt: &fetchedAt}
+	if err := s.convertDocument(doc, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ConvertHTML is a convenience wrapper that converts r with a scraper built
+// from config (nil uses the defaults).
+func ConvertHTML(r io.Reader, pageURL string, config *ScrapingConfig) (*ScrapedPage, error) {
+	return NewScraper(config).ConvertHTML(r, pageURL)
+}
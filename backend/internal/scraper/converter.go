@@ -0,0 +1,377 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/JohannesKaufmann/html-to-markdown/plugin"
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	HEADING_ATX    = "atx"    // # Heading
+	HEADING_SETEXT = "setext" // Heading, underlined with === or ---
+
+	LINK_INLINED    = "inlined"    // [text](url)
+	LINK_REFERENCED = "referenced" // [text][1], with the URLs at the end of the page
+)
+
+// ConverterOptions sets how HTML is written as Markdown. The zero value
+// gives ATX headings, "-" bullets, ``` fences, inlined links and the GitHub
+// Flavored Markdown tables, strikethrough and task lists, with tables that
+// don't fit a pipe table kept as HTML.
+type ConverterOptions struct {
+	HeadingStyle     string `json:"headingStyle,omitempty"`     // HEADING_ATX or HEADING_SETEXT
+	BulletListMarker string `json:"bulletListMarker,omitempty"` // "-", "+" or "*"
+	Fence            string `json:"fence,omitempty"`            // "```" or "~~~"
+	LinkStyle        string `json:"linkStyle,omitempty"`        // LINK_INLINED or LINK_REFERENCED
+	DisableGFM       bool   `json:"disableGfm,omitempty"`       // plain CommonMark, no pipe tables
+	Tables           string `json:"tables,omitempty"`           // TABLES_HTML or TABLES_RECORDS, for tables that aren't pipe tables
+
+	// Rules take precedence over the registered rules, for this scraper
+	// only.
+	Rules []ElementRule `json:"-"`
+}
+
+// Validate fills in defaults and checks the options.
+func (o *ConverterOptions) Validate() error {
+	if o.HeadingStyle == "" {
+		o.HeadingStyle = HEADING_ATX
+	}
+	if o.HeadingStyle != HEADING_ATX && o.HeadingStyle != HEADING_SETEXT {
+		return fmt.Errorf("unknown heading style %q: use atx or setext", o.HeadingStyle)
+	}
+	if o.BulletListMarker == "" {
+		o.BulletListMarker = "-"
+	}
+	if o.BulletListMarker != "-" && o.BulletListMarker != "+" && o.BulletListMarker != "*" {
+		return fmt.Errorf("unknown bullet list marker %q: use -, + or *", o.BulletListMarker)
+	}
+	if o.Fence == "" {
+		o.Fence = "```"
+	}
+	if o.Fence != "```" && o.Fence != "~~~" {
+		return fmt.Errorf("unknown code fence %q: use ``` or ~~~", o.Fence)
+	}
+	if o.LinkStyle == "" {
+		o.LinkStyle = LINK_INLINED
+	}
+	if o.LinkStyle != LINK_INLINED && o.LinkStyle != LINK_REFERENCED {
+		return fmt.Errorf("unknown link style %q: use inlined or referenced", o.LinkStyle)
+	}
+	if o.Tables == "" {
+		o.Tables = TABLES_HTML
+	}
+	if o.Tables != TABLES_HTML && o.Tables != TABLES_RECORDS {
+		return fmt.Errorf("unknown table mode %q: use html or records", o.Tables)
+	}
+	for _, rule := range o.Rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ElementRule converts the elements it matches to Markdown in place of the
+// built-in rules, for widgets such as callouts and tabs that have no
+// Markdown counterpart.
+type ElementRule struct {
+	Name string // replaces the registered rule of the same name
+
+	// Tags lists the element names the rule sees; Selector, if set,
+	// narrows them down (e.g. Tags: div, Selector: ".callout").
+	Tags     []string
+	Selector string
+
+	// Convert gets the Markdown of the element's content and returns what
+	// to write instead of the element, or false to leave it to the other
+	// rules.
+	Convert func(content string, element *goquery.Selection) (string, bool)
+}
+
+func (r ElementRule) validate() error {
+	if len(r.Tags) == 0 || r.Convert == nil {
+		return fmt.Errorf("rule %q needs Tags and Convert", r.Name)
+	}
+	return nil
+}
+
+func (r ElementRule) mdRule() md.Rule {
+	return md.Rule{
+		Filter: r.Tags,
+		Replacement: func(content string, selec *goquery.Selection, _ *md.Options) *string {
+			if r.Selector != "" && !selec.Is(r.Selector) {
+				return nil
+			}
+			markdown, ok := r.Convert(content, selec)
+			if !ok {
+				return nil
+			}
+			return &markdown
+		},
+	}
+}
+
+var (
+	rulesMutex sync.RWMutex
+	// Built in, in order of increasing precedence
+	registeredRules = []ElementRule{calloutRule, tabListRule, tabPanelRule}
+)
+
+// RegisterRule adds rules to every Scraper created afterwards. A rule with
+// the name of a registered one replaces it, so site profiles and library
+// users can also override the built-in "callout", "tab-list" and
+// "tab-panel" rules. Later rules take precedence. If a rule has no Tags or
+// Convert, none of the rules are registered.
+func RegisterRule(rules ...ElementRule) error {
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+
+	for _, rule := range rules {
+		replaced := false
+		for i, registered := range registeredRules {
+			if rule.Name != "" && registered.Name == rule.Name {
+				registeredRules[i] = rule
+				replaced = true
+			}
+		}
+		if !replaced {
+			registeredRules = append(registeredRules, rule)
+		}
+	}
+	return nil
+}
+
+var (
+	linkDefinitionPattern = regexp.MustCompile(`(?m)^\[(\d+)\]: (\S+)(?: "(.*)")?[ \t]*$`)
+	referenceLinkPattern  = regexp.MustCompile(`\[((?:[^\[\]]|\[[^\]]*\])*)\]\[(\d+)\]`)
+	indentedCodePattern   = regexp.MustCompile(`^(    |\t)`)
+	listItemPattern       = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s`)
+)
+
+// InlineLinkReferences rewrites the [text][1] links of LINK_REFERENCED
+// Markdown as [text](url) and drops the definitions at the end of the page,
+// so chunks keep their URLs and pages can be joined without their numbers
+// clashing. Code blocks are left alone. Markdown without definitions is
+// returned as is.
+func InlineLinkReferences(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	code := codeLines(lines)
+
+	definitions := make(map[string]string)
+	for i, line := range lines {
+		match := linkDefinitionPattern.FindStringSubmatch(line)
+		if match == nil || code[i] {
+			continue
+		}
+		target := match[2]
+		if match[3] != "" {
+			target += ` "` + match[3] + `"`
+		}
+		definitions[match[1]] = target
+	}
+	if len(definitions) == 0 {
+		return markdown
+	}
+
+	var inlined []string
+	for i, line := range lines {
+		if code[i] {
+			inlined = append(inlined, line)
+			continue
+		}
+		if linkDefinitionPattern.MatchString(line) {
+			continue
+		}
+		inlined = append(inlined, referenceLinkPattern.ReplaceAllStringFunc(line, func(link string) string {
+			match := referenceLinkPattern.FindStringSubmatch(link)
+			target, ok := definitions[match[2]]
+			if !ok {
+				return link
+			}
+			return "[" + match[1] + "](" + target + ")"
+		}))
+	}
+	return strings.TrimRight(strings.Join(inlined, "\n"), "\n")
+}
+
+// codeLines reports which lines are code: fenced, or indented after a blank
+// line where they don't continue a list item.
+func codeLines(lines []string) []bool {
+	code := make([]bool, len(lines))
+	inFence, inIndented, inList := false, false, false
+	previousBlank := true
+	for i, line := range lines {
+		blank := strings.TrimSpace(line) == ""
+		switch {
+		case fencePattern.MatchString(line):
+			code[i] = true
+			inFence = !inFence
+		case inFence:
+			code[i] = true
+		case blank:
+		case indentedCodePattern.MatchString(line) && (inIndented || previousBlank && !inList):
+			code[i] = true
+			inIndented = true
+		default:
+			inIndented = false
+			if listItemPattern.MatchString(line) {
+				inList = true
+			} else if !indentedCodePattern.MatchString(line) {
+				inList = false
+			}
+		}
+		previousBlank = blank
+	}
+	return code
+}
+
+// newConverter builds the HTML to Markdown converter for options, which
+// must have been validated.
+func newConverter(options ConverterOptions) *md.Converter {
+	converter := md.NewConverter("", true, &md.Options{
+		HeadingStyle:     options.HeadingStyle,
+		BulletListMarker: options.BulletListMarker,
+		Fence:            options.Fence,
+		LinkStyle:        options.LinkStyle,
+	})
+	if !options.DisableGFM {
+		converter.Use(plugin.GitHubFlavored())
+	}
+	converter.AddRules(tableRules()...)
+
+	rulesMutex.RLock()
+	rules := append([]ElementRule(nil), registeredRules...)
+	rulesMutex.RUnlock()
+
+	// Rules added last are tried first
+	for _, rule := range append(rules, options.Rules...) {
+		converter.AddRules(rule.mdRule())
+	}
+	return converter
+}
+
+// Callout kinds by the class words that mark them, as GitHub alerts
+var calloutKinds = []struct{ word, kind string }{
+	{"important", "IMPORTANT"},
+	{"caution", "CAUTION"},
+	{"danger", "CAUTION"},
+	{"error", "CAUTION"},
+	{"warning", "WARNING"},
+	{"attention", "WARNING"},
+	{"tip", "TIP"},
+	{"hint", "TIP"},
+	{"success", "TIP"},
+	{"note", "NOTE"},
+	{"info", "NOTE"},
+}
+
+// calloutRule writes admonitions (MkDocs, Sphinx, Docusaurus, VitePress,
+// Starlight, GitHub) as GitHub alerts: > [!NOTE]. Bootstrap's .alert is left
+// out, as sites use it for cookie and consent banners as much as for notes.
+var calloutRule = ElementRule{
+	Name:     "callout",
+	Tags:     []string{"div", "aside", "section"},
+	Selector: ".admonition, .callout, .custom-block, .markdown-alert, aside[class*=aside], [role=note]",
+	Convert: func(content string, element *goquery.Selection) (string, bool) {
+		kind, word := "NOTE", "note"
+		class := strings.ToLower(element.AttrOr("class", ""))
+	search:
+		for _, name := range strings.Fields(class) {
+			for _, candidate := range calloutKinds {
+				if strings.Contains(name, candidate.word) {
+					kind, word = candidate.kind, candidate.word
+					break search
+				}
+			}
+		}
+
+		// The title is written again as the first line of the content
+		body := strings.TrimSpace(content)
+		title := element.Find(".admonition-title, .callout-title, .custom-block-title, .markdown-alert-title, .admonition-heading").First()
+		if titleText := strings.TrimSpace(title.Text()); titleText != "" {
+			if first, rest, _ := strings.Cut(body, "\n"); strings.Contains(first, titleText) {
+				body = strings.TrimSpace(rest)
+			}
+			if !strings.EqualFold(titleText, word) && !strings.EqualFold(titleText, kind) {
+				body = "**" + titleText + "**\n\n" + body
+			}
+		}
+		lines := strings.Split(multipleBlankLines.ReplaceAllString(body, "\n\n"), "\n")
+
+		var alert strings.Builder
+		alert.WriteString("\n\n> [!" + kind + "]\n")
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				alert.WriteString(">\n")
+				continue
+			}
+			alert.WriteString("> " + line + "\n")
+		}
+		alert.WriteString("\n")
+		return alert.String(), true
+	},
+}
+
+const (
+	tabSelector      = "[role=tab], .vp-code-group .tabs label"
+	tabPanelSelector = "[role=tabpanel], .vp-code-group .blocks > div"
+)
+
+// tabListRule drops the tab buttons; tabPanelRule writes their labels
+// above the panels instead, so no tab's content is hidden.
+var tabListRule = ElementRule{
+	Name:     "tab-list",
+	Tags:     []string{"div", "ul", "ol", "nav"},
+	Selector: "[role=tablist], .vp-code-group .tabs",
+	Convert: func(string, *goquery.Selection) (string, bool) {
+		return "", true
+	},
+}
+
+var tabPanelRule = ElementRule{
+	Name:     "tab-panel",
+	Tags:     []string{"div", "section"},
+	Selector: tabPanelSelector,
+	Convert: func(content string, element *goquery.Selection) (string, bool) {
+		label := tabLabel(element)
+		if label == "" {
+			return "", false
+		}
+		return "\n\n**" + label + "**\n\n" + strings.TrimSpace(content) + "\n\n", true
+	},
+}
+
+// tabLabel finds the tab of a panel by aria-labelledby or else by position.
+func tabLabel(panel *goquery.Selection) string {
+	if id := panel.AttrOr("aria-labelledby", ""); id != "" {
+		tab := panel.Parents().Last().Find("[id]").FilterFunction(func(_ int, s *goquery.Selection) bool {
+			return s.AttrOr("id", "") == id
+		})
+		if label := strings.TrimSpace(tab.First().Text()); label != "" {
+			return strings.Join(strings.Fields(label), " ")
+		}
+	}
+
+	for parent := panel.Parent(); parent.Length() > 0; parent = parent.Parent() {
+		tabs := parent.Find(tabSelector)
+		if tabs.Length() == 0 {
+			continue
+		}
+		if i := parent.Find(tabPanelSelector).IndexOfSelection(panel); i >= 0 && i < tabs.Length() {
+			return strings.Join(strings.Fields(tabs.Eq(i).Text()), " ")
+		}
+		break
+	}
+	return ""
+}
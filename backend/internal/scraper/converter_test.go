@@ -0,0 +1,97 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalloutRule(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"admonition", `<div class="admonition warning"><p class="admonition-title">Warning</p><p>Mind the gap.</p></div>`, "> [!WARNING]\n> Mind the gap."},
+		{"bootstrap alert", `<div class="alert alert-dismissible">We use cookies.</div>`, "We use cookies."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := ConverterOptions{}
+			if err := options.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			markdown, err := newConverter(options).ConvertString(test.html)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(markdown); got != test.want {
+				t.Errorf("Markdown = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRegisterRuleInvalid(t *testing.T) {
+	rulesMutex.RLock()
+	before := len(registeredRules)
+	rulesMutex.RUnlock()
+
+	err := RegisterRule(ElementRule{Name: "empty", Tags: []string{"span"}})
+	if err == nil {
+		t.Fatal("RegisterRule() without Convert succeeded")
+	}
+
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+	if len(registeredRules) != before {
+		t.Errorf("an invalid rule was registered")
+	}
+}
+
+func TestInlineLinkReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			"definitions at the end",
+			"See [the docs][1] and [![logo][]][2].\n\n[1]: https://a.example/x \"T\"\n[2]: https://b.example/",
+			"See [the docs](https://a.example/x \"T\") and [![logo][]](https://b.example/).",
+		},
+		{
+			"definition in a fence",
+			"Use [it][1]:\n\n```\n[1]: https://example.com/config\nsee [x][1]\n```\n\n[1]: https://a.example/",
+			"Use [it](https://a.example/):\n\n```\n[1]: https://example.com/config\nsee [x][1]\n```",
+		},
+		{
+			"indented code",
+			"Use [it][1]:\n\n    see [x][1]\n\n[1]: https://a.example/",
+			"Use [it](https://a.example/):\n\n    see [x][1]",
+		},
+		{
+			"list item continuation",
+			"- Item\n\n    with [a link][1]\n\n[1]: https://a.example/",
+			"- Item\n\n    with [a link](https://a.example/)",
+		},
+		{
+			"only a fenced definition",
+			"```\n[1]: https://example.com/\n```",
+			"```\n[1]: https://example.com/\n```",
+		},
+		{
+			"no definitions",
+			"No [references](https://example.com) here.\n",
+			"No [references](https://example.com) here.\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := InlineLinkReferences(test.markdown); got != test.want {
+				t.Errorf("InlineLinkReferences() =\n%q\nwant\n%q", got, test.want)
+			}
+		})
+	}
+}
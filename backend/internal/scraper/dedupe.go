@@ -0,0 +1,257 @@
+package scraper
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	DUPLICATES_OFF   = "off"   // keep every page
+	DUPLICATES_GROUP = "group" // keep near-duplicates, marked with DuplicateOf
+	DUPLICATES_DROP  = "drop"  // leave near-duplicates out of the results
+
+	// Thresholds are the share of the 64 SimHash bits two pages agree on,
+	// not a Jaccard similarity of their text: 0.9 lets 6 bits differ
+	DEFAULT_DUPLICATE_THRESHOLD = 0.9
+
+	// Pages with fewer words only match exact copies, since a few shared
+	// words would make their fingerprints look alike
+	MIN_FINGERPRINT_WORDS = 20
+	FINGERPRINT_SHINGLE   = 3 // words per shingle
+)
+
+// DuplicateCluster is a page and the pages found to have (nearly) the same
+// content.
+type DuplicateCluster struct {
+	URL        string          `json:"url"` // first page seen with this content
+	Duplicates []DuplicatePage `json:"duplicates"`
+}
+
+// DuplicatePage is a page of a DuplicateCluster and how similar it is to the
+// cluster's first page.
+type DuplicatePage struct {
+	URL        string  `json:"url"`
+	Similarity float64 `json:"similarity"` // 1 for identical Markdown
+}
+
+// PageFingerprint is what the duplicate detector remembers of a page.
+type PageFingerprint struct {
+	URL         string `json:"url"`
+	ContentHash string `json:"contentHash"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// ValidateDuplicates checks a duplicate mode and threshold.
+func ValidateDuplicates(mode string, threshold float64) error {
+	switch mode {
+	case "", DUPLICATES_OFF, DUPLICATES_GROUP, DUPLICATES_DROP:
+	default:
+		return fmt.Errorf("unknown duplicate mode %q: use off, group or drop", mode)
+	}
+	if threshold < 0.5 || threshold > 1 {
+		return fmt.Errorf("duplicate threshold must be between 0.5 and 1")
+	}
+	return nil
+}
+
+// fingerprint returns the SimHash of markdown's word shingles, or "" for
+// pages too short to fingerprint. Pages whose fingerprints differ in few
+// bits share most of their shingles.
+func fingerprint(markdown string) string {
+	words := strings.FieldsFunc(strings.ToLower(markdown), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < MIN_FINGERPRINT_WORDS {
+		return ""
+	}
+
+	var weights [64]int
+	for i := 0; i+FINGERPRINT_SHINGLE <= len(words); i++ {
+		hash := fnv.New64a()
+		hash.Write([]byte(strings.Join(words[i:i+FINGERPRINT_SHINGLE], " ")))
+		sum := hash.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var simhash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			simhash |= 1 << bit
+		}
+	}
+	return fmt.Sprintf("simhash:%016x", simhash)
+}
+
+// similarity compares two pages: 1 for identical content, else the share
+// of matching fingerprint bits (0 if either page has no fingerprint).
+func similarity(a, b PageFingerprint) float64 {
+	if a.ContentHash != "" && a.ContentHash == b.ContentHash {
+		return 1
+	}
+	x, okA := parseFingerprint(a.Fingerprint)
+	y, okB := parseFingerprint(b.Fingerprint)
+	if !okA || !okB {
+		return 0
+	}
+	return 1 - float64(bits.OnesCount64(x^y))/64
+}
+
+func parseFingerprint(value string) (uint64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	simhash, err := strconv.ParseUint(strings.TrimPrefix(value, "simhash:"), 16, 64)
+	return simhash, err == nil
+}
+
+// duplicateDetector compares each page with the distinct pages seen before
+// it. Fingerprints are split into bands, one more than the number of bits
+// the threshold lets differ, so a page within the threshold of another
+// matches it exactly in at least one band; only pages that do are compared.
+type duplicateDetector struct {
+	threshold float64
+	bands     []uint64              // bit mask of each band
+	buckets   map[simhashBand][]int // band value -> positions in pages
+	pages     []PageFingerprint     // distinct pages, in the order they were seen
+	byHash    map[string]string     // content hash -> URL of the first page
+	clusters  []DuplicateCluster
+	index     map[string]int // first page URL -> position in clusters
+}
+
+type simhashBand struct {
+	band  int
+	value uint64
+}
+
+func newDuplicateDetector(threshold float64) *duplicateDetector {
+	// Float error must not round a whole number of bits down
+	maxDistance := int((1-threshold)*64 + 1e-9)
+	count := min(maxDistance+1, 64)
+
+	var bands []uint64
+	for band, start := 0, 0; band < count; band++ {
+		width := (64 - start) / (count - band)
+		bands = append(bands, (1<<width-1)<<start)
+		start += width
+	}
+
+	return &duplicateDetector{
+		threshold: threshold,
+		bands:     bands,
+		buckets:   make(map[simhashBand][]int),
+		byHash:    make(map[string]string),
+		index:     make(map[string]int),
+	}
+}
+
+// candidates returns the positions of the pages that share a band with
+// simhash, each once.
+func (d *duplicateDetector) candidates(simhash uint64) []int {
+	var positions []int
+	seen := make(map[int]bool)
+	for band, mask := range d.bands {
+		for _, i := range d.buckets[simhashBand{band, simhash & mask}] {
+			if !seen[i] {
+				seen[i] = true
+				positions = append(positions, i)
+			}
+		}
+	}
+	return positions
+}
+
+// check records page and sets its DuplicateOf if an earlier page has the
+// same or nearly the same content.
+func (d *duplicateDetector) check(page *ScrapedPage) bool {
+	current := PageFingerprint{URL: page.URL, ContentHash: page.ContentHash, Fingerprint: page.Fingerprint}
+
+	// A page seen again after a resume is not a copy of itself
+	original, best := "", 0.0
+	if firstURL, exists := d.byHash[page.ContentHash]; exists {
+		if firstURL == page.URL {
+			return false
+		}
+		original, best = firstURL, 1
+	} else if simhash, ok := parseFingerprint(current.Fingerprint); ok {
+		position := -1
+		for _, i := range d.candidates(simhash) {
+			seen := d.pages[i]
+			if seen.URL == page.URL {
+				continue
+			}
+			// Ties go to the page seen first
+			if score := similarity(current, seen); score >= d.threshold &&
+				(score > best || score == best && i < position) {
+				original, best, position = seen.URL, score, i
+			}
+		}
+	}
+
+	if original == "" {
+		d.remember(current)
+		return false
+	}
+
+	page.DuplicateOf = original
+	d.addDuplicate(original, DuplicatePage{URL: page.URL, Similarity: best})
+	return true
+}
+
+func (d *duplicateDetector) remember(page PageFingerprint) {
+	if simhash, ok := parseFingerprint(page.Fingerprint); ok {
+		for band, mask := range d.bands {
+			key := simhashBand{band, simhash & mask}
+			d.buckets[key] = append(d.buckets[key], len(d.pages))
+		}
+	}
+	d.pages = append(d.pages, page)
+	if _, exists := d.byHash[page.ContentHash]; !exists {
+		d.byHash[page.ContentHash] = page.URL
+	}
+}
+
+func (d *duplicateDetector) addDuplicate(original string, duplicate DuplicatePage) {
+	i, exists := d.index[original]
+	if !exists {
+		i = len(d.clusters)
+		d.index[original] = i
+		d.clusters = append(d.clusters, DuplicateCluster{URL: original})
+	}
+	d.clusters[i].Duplicates = append(d.clusters[i].Duplicates, duplicate)
+}
+
+// restore continues from the fingerprints and clusters of a checkpoint.
+func (d *duplicateDetector) restore(pages []PageFingerprint, clusters []DuplicateCluster) {
+	for _, page := range pages {
+		d.remember(page)
+	}
+	for _, cluster := range clusters {
+		for _, duplicate := range cluster.Duplicates {
+			d.addDuplicate(cluster.URL, duplicate)
+		}
+	}
+}
+
+// DuplicateClusters returns the near-duplicate clusters found so far, in the
+// order their first page was seen. It is empty unless Duplicates is set to
+// DUPLICATES_GROUP or DUPLICATES_DROP.
+func (s *Scraper) DuplicateClusters() []DuplicateCluster {
+	if s.dedupe == nil {
+		return nil
+	}
+	clusters := make([]DuplicateCluster, len(s.dedupe.clusters))
+	for i, cluster := range s.dedupe.clusters {
+		clusters[i] = DuplicateCluster{URL: cluster.URL, Duplicates: append([]DuplicatePage(nil), cluster.Duplicates...)}
+	}
+	return clusters
+}
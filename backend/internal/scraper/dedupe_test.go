@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSimilarityIsBitAgreement(t *testing.T) {
+	page := func(simhash uint64) PageFingerprint {
+		return PageFingerprint{ContentHash: fmt.Sprint(simhash), Fingerprint: fmt.Sprintf("simhash:%016x", simhash)}
+	}
+
+	tests := []struct {
+		name string
+		a, b PageFingerprint
+		want float64
+	}{
+		{"same content", PageFingerprint{ContentHash: "h"}, PageFingerprint{ContentHash: "h"}, 1},
+		{"no fingerprint", PageFingerprint{ContentHash: "a"}, page(0), 0},
+		{"one bit", page(0), page(1), 63.0 / 64},
+		{"six bits", page(0), page(0x3f), 58.0 / 64},
+		{"seven bits", page(0), page(0x7f), 57.0 / 64},
+		{"opposite", page(0), page(^uint64(0)), 0},
+	}
+	for _, test := range tests {
+		if got := similarity(test.a, test.b); got != test.want {
+			t.Errorf("%s: similarity() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	words := func(n, offset int) string {
+		var text []string
+		for i := 0; i < n; i++ {
+			text = append(text, fmt.Sprintf("word%d", (i+offset)%200))
+		}
+		return strings.Join(text, " ")
+	}
+	similar := func(a, b string) float64 {
+		return similarity(PageFingerprint{Fingerprint: fingerprint(a)}, PageFingerprint{Fingerprint: fingerprint(b)})
+	}
+
+	if got := fingerprint(words(MIN_FINGERPRINT_WORDS-1, 0)); got != "" {
+		t.Errorf("fingerprint() of a short page = %q, want none", got)
+	}
+	long := words(150, 0)
+	if got := similar(long, long+" one more"); got < DEFAULT_DUPLICATE_THRESHOLD {
+		t.Errorf("a page with two more words has similarity %v, want at least %v", got, DEFAULT_DUPLICATE_THRESHOLD)
+	}
+	if got := similar(long, words(150, 100)); got >= DEFAULT_DUPLICATE_THRESHOLD {
+		t.Errorf("pages sharing a third of their text have similarity %v, want less than %v", got, DEFAULT_DUPLICATE_THRESHOLD)
+	}
+}
+
+// The banded lookup must find the same originals as comparing every pair.
+func TestDuplicateDetectorBands(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for _, threshold := range []float64{0.5, 0.75, 0.9, 58.0 / 64, 0.95, 1} {
+		detector := newDuplicateDetector(threshold)
+		var seen []uint64
+		for i := 0; i < 400; i++ {
+			simhash := random.Uint64()
+			if len(seen) > 0 && i%2 == 0 {
+				// A near copy of an earlier page: flip a few bits
+				simhash = seen[random.Intn(len(seen))]
+				for flips := random.Intn(10); flips > 0; flips-- {
+					simhash ^= 1 << random.Intn(64)
+				}
+			}
+
+			// The closest earlier page within the threshold, the first on ties
+			want, closest := "", 65
+			for j, other := range seen {
+				distance := bits.OnesCount64(simhash ^ other)
+				if 1-float64(distance)/64 >= threshold && distance < closest {
+					want, closest = fmt.Sprintf("https://example.com/%d", j), distance
+				}
+			}
+
+			page := &ScrapedPage{
+				URL:         fmt.Sprintf("https://example.com/%d", len(seen)),
+				ContentHash: fmt.Sprintf("hash-%d", i),
+				Fingerprint: fmt.Sprintf("simhash:%016x", simhash),
+			}
+			detector.check(page)
+			if page.DuplicateOf != want {
+				t.Fatalf("threshold %v, page %d: DuplicateOf = %q, want %q", threshold, i, page.DuplicateOf, want)
+			}
+			if want == "" {
+				seen = append(seen, simhash)
+			}
+		}
+	}
+}
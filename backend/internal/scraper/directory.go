@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var nonHostChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// DirectoryURL returns the site root URL that ScrapeDirectory gives dir:
+// file://<dir name>/, e.g. file://public/ for ./public.
+func DirectoryURL(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+
+	host := nonHostChars.ReplaceAllString(strings.ToLower(filepath.Base(abs)), "-")
+	host = strings.Trim(host, "-.")
+	if host == "" {
+		host = "site"
+	}
+	return "file://" + host + "/"
+}
+
+// ScrapeDirectory converts a static site build (such as Hugo's or Jekyll's
+// public/ output) without a web server. Every .html file under dir is
+// scraped, links between them are resolved as on the live site, and nothing
+// is fetched over the network. Pages get URLs below DirectoryURL(dir).
+//
+// The directory replaces the configured Fetcher and no Delay is applied.
+func (s *Scraper) ScrapeDirectory(ctx context.Context, dir string, sink PageSink) error {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("🚫 Invalid directory %s: %v", dir, err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("🚫 Not a directory: %s", dir)
+	}
+
+	siteURL, _ := url.Parse(DirectoryURL(root))
+	seed := s.normalizeURL(siteURL.String())
+	s.addSeed(seed, siteURL.Host)
+	s.fetcher = FileFetcher{Root: root}
+	s.polite = newPoliteness(s.config.Concurrency, 0)
+
+	var targets []crawlTarget
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !isHTMLFile(path) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		// docs/index.html is the page at docs/, as a web server would serve it
+		rel = filepath.ToSlash(rel)
+		if filepath.Base(rel) == "index.html" {
+			rel = strings.TrimSuffix(rel, "index.html")
+		}
+
+		pageURL := siteURL.ResolveReference(&url.URL{Path: rel})
+		targets = append(targets, crawlTarget{URL: s.normalizeURL(pageURL.String()), Seed: seed})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("❌ Failed to read directory %s: %v", dir, err)
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("🚫 No HTML files found in %s", dir)
+	}
+
+	s.logf("📂 Converting %d HTML files from %s (as %s)\n", len(targets), root, seed)
+	return s.crawl(ctx, targets, sink)
+}
+
+func isHTMLFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".html" || ext == ".htm"
+}
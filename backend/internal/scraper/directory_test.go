@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeSiteDir writes the pages of linkedSite to dir, each at its path with
+// .html appended (index.html for the directories).
+func writeSiteDir(t *testing.T, dir string, links map[string][]string) {
+	t.Helper()
+	for pageURL, body := range linkedSite(links) {
+		path := strings.TrimPrefix(pageURL, "https://example.com/")
+		if path == "" || strings.HasSuffix(path, "/") {
+			path += "index"
+		}
+		path = filepath.Join(dir, filepath.FromSlash(path)+".html")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDirectoryURL(t *testing.T) {
+	for dir, want := range map[string]string{
+		"/srv/My Site":    "file://my-site/",
+		"/srv/public":     "file://public/",
+		"/srv/docs.build": "file://docs.build/",
+		"/srv/__":         "file://site/",
+	} {
+		if got := DirectoryURL(dir); got != want {
+			t.Errorf("DirectoryURL(%q) = %s, want %s", dir, got, want)
+		}
+	}
+}
+
+func TestScrapeDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "public")
+	writeSiteDir(t, dir, map[string][]string{
+		"/":         {"about.html", "docs/"},
+		"/about":    nil,
+		"/docs/":    {"../about.html"},
+		"/orphan":   nil, // linked from nowhere, converted all the same
+		"/missing/": {"gone.html"},
+	})
+	if err := os.WriteFile(filepath.Join(dir, "style.css"), []byte("body {}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &collectSink{}
+	config := &ScrapingConfig{MaxDepth: 1, Concurrency: 1, Log: io.Discard}
+	if err := NewScraper(config).ScrapeDirectory(context.Background(), dir, sink); err != nil {
+		t.Fatal(err)
+	}
+
+	// The link from docs/ to ../about.html is the page already converted,
+	// and the one to a missing file is recorded as failed
+	var converted, failed []string
+	for _, page := range sink.pages {
+		if page.Error != "" {
+			failed = append(failed, page.URL)
+		} else {
+			converted = append(converted, page.URL)
+		}
+	}
+	sort.Strings(converted)
+	want := "file://public/ file://public/about.html file://public/docs file://public/missing file://public/orphan.html"
+	if got := strings.Join(converted, " "); got != want {
+		t.Errorf("converted\n%s\nwant every HTML file, once, without the stylesheet:\n%s", got, want)
+	}
+	if len(failed) != 1 || failed[0] != "file://public/missing/gone.html" {
+		t.Errorf("failed pages = %v, want the missing file", failed)
+	}
+}
+
+func TestScrapeDirectoryWithoutHTML(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := NewScraper(&ScrapingConfig{Log: io.Discard}).ScrapeDirectory(context.Background(), dir, &collectSink{})
+	if err == nil || !strings.Contains(err.Error(), "No HTML files") {
+		t.Errorf("ScrapeDirectory() error = %v, want no HTML files found", err)
+	}
+}
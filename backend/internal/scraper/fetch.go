@@ -0,0 +1,201 @@
+package scraper
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FetchResponse is what a Fetcher returns for a URL. The caller closes Body.
+type FetchResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       io.ReadCloser
+	URL        string // final URL after redirects, used to resolve relative links
+}
+
+// Fetcher retrieves the raw response for a URL. A non-2xx status is not an
+// error; errors are reserved for failures to get a response at all.
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*FetchResponse, error)
+}
+
+// HTTPFetcher fetches http and https URLs. It is the default Fetcher.
+type HTTPFetcher struct {
+	Client    *http.Client
+	UserAgent string
+}
+
+func NewHTTPFetcher(userAgent string) *HTTPFetcher {
+	return &HTTPFetcher{
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		UserAgent: userAgent,
+	}
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (*FetchResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", f.UserAgent)
+	// Asking for gzip ourselves turns off the transport's transparent
+	// decompression, so a WARC transport sees the response as it was sent
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := decodeBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return &FetchResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		URL:        resp.Request.URL.String(),
+	}, nil
+}
+
+// decodeBody undoes the gzip Content-Encoding of resp, if any, and drops
+// the headers that describe the encoded body, as http.Transport does.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp.Body, nil
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err == io.EOF {
+		return resp.Body, nil // no body, as with HEAD or 304
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip response: %v", err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, resp.Body}, nil
+}
+
+// FileFetcher serves file:// URLs from the local filesystem. A directory is
+// served by its index.html, and an extensionless path falls back to
+// path.html; missing files are reported as 404.
+type FileFetcher struct {
+	// Root, if set, serves URL paths relative to this directory instead of
+	// the filesystem root, like a web server's document root. Paths that
+	// lead outside it, such as /../secret, are refused.
+	Root string
+}
+
+func (f FileFetcher) Fetch(ctx context.Context, rawURL string) (*FetchResponse, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsedURL.Scheme != "file" {
+		return nil, fmt.Errorf("unsupported protocol scheme %q", parsedURL.Scheme)
+	}
+
+	path := filepath.FromSlash(parsedURL.Path)
+	if f.Root != "" {
+		root := filepath.Clean(f.Root)
+		path = filepath.Join(root, path)
+		if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+			return nil, fmt.Errorf("%s is outside the root directory", rawURL)
+		}
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "index.html")
+		parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/") + "/index.html"
+	} else if os.IsNotExist(err) && filepath.Ext(path) == "" {
+		path += ".html"
+		parsedURL.Path += ".html"
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return notFoundResponse(rawURL), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	return &FetchResponse{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       file,
+		URL:        parsedURL.String(),
+	}, nil
+}
+
+// MemoryFetcher serves HTML documents from a map of URL to HTML, which is
+// handy for tests and for crawling generated content. URLs not in the map
+// are reported as 404.
+type MemoryFetcher map[string]string
+
+func (m MemoryFetcher) Fetch(ctx context.Context, rawURL string) (*FetchResponse, error) {
+	html, exists := m[rawURL]
+	if !exists {
+		// Crawled URLs are normalized without a trailing slash
+		html, exists = m[rawURL+"/"]
+	}
+	if !exists {
+		return notFoundResponse(rawURL), nil
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	return &FetchResponse{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(html)),
+		URL:        rawURL,
+	}, nil
+}
+
+// SchemeFetcher dispatches to a Fetcher by URL scheme, e.g. "https" or "file".
+type SchemeFetcher map[string]Fetcher
+
+func (m SchemeFetcher) Fetch(ctx context.Context, rawURL string) (*FetchResponse, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher, exists := m[strings.ToLower(parsedURL.Scheme)]
+	if !exists {
+		return nil, fmt.Errorf("unsupported protocol scheme %q", parsedURL.Scheme)
+	}
+	return fetcher.Fetch(ctx, rawURL)
+}
+
+func notFoundResponse(rawURL string) *FetchResponse {
+	return &FetchResponse{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		URL:        rawURL,
+	}
+}
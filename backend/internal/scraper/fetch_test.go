@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileFetcher(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "site")
+	for path, content := range map[string]string{
+		"site/index.html":      "home",
+		"site/docs/index.html": "docs",
+		"site/about.html":      "about",
+		"secret.html":          "secret",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fetcher := FileFetcher{Root: root}
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+		final  string
+	}{
+		{"file:///", http.StatusOK, "home", "file:///index.html"},
+		{"file:///docs/", http.StatusOK, "docs", "file:///docs/index.html"},
+		{"file:///about", http.StatusOK, "about", "file:///about.html"},
+		{"file:///missing.html", http.StatusNotFound, "", "file:///missing.html"},
+	}
+	for _, test := range tests {
+		resp, err := fetcher.Fetch(context.Background(), test.url)
+		if err != nil {
+			t.Fatalf("Fetch(%s) error = %v", test.url, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != test.status || string(body) != test.body || resp.URL != test.final {
+			t.Errorf("Fetch(%s) = %d %q at %s, want %d %q at %s", test.url, resp.StatusCode, body, resp.URL, test.status, test.body, test.final)
+		}
+	}
+
+	// Neither plain nor encoded dot segments get out of the root
+	for _, rawURL := range []string{"file:///../secret.html", "file:///%2e%2e/secret.html", "file:///docs/%2E%2E/%2e%2e/secret"} {
+		if resp, err := fetcher.Fetch(context.Background(), rawURL); err == nil {
+			resp.Body.Close()
+			t.Errorf("Fetch(%s) = %d, want it refused", rawURL, resp.StatusCode)
+		}
+	}
+}
+
+func TestSchemeFetcher(t *testing.T) {
+	fetcher := SchemeFetcher{"https": MemoryFetcher{"https://example.com/": "page"}}
+
+	resp, err := fetcher.Fetch(context.Background(), "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Fetch() = %d, want the page", resp.StatusCode)
+	}
+	if _, err := fetcher.Fetch(context.Background(), "ftp://example.com/"); err == nil {
+		t.Error("Fetch() of an unregistered scheme succeeded")
+	}
+}
@@ -0,0 +1,243 @@
+package scraper
+
+import (
+	"container/heap"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Ordering decides which queued URL is scraped next: the frontier hands out
+// the entry with the highest score first, and the oldest one among equal
+// scores.
+type Ordering interface {
+	Score(entry FrontierEntry) float64
+}
+
+// OrderingFunc adapts a function to an Ordering.
+type OrderingFunc func(entry FrontierEntry) float64
+
+func (f OrderingFunc) Score(entry FrontierEntry) float64 {
+	return f(entry)
+}
+
+var (
+	// BreadthFirst scrapes shallow pages first. It is the default.
+	BreadthFirst Ordering = OrderingFunc(func(entry FrontierEntry) float64 {
+		return -float64(entry.Depth)
+	})
+	// DepthFirst follows links as deep as MaxDepth allows before going back.
+	DepthFirst Ordering = OrderingFunc(func(entry FrontierEntry) float64 {
+		return float64(entry.Depth)
+	})
+)
+
+// URLPattern gives URLs that match Pattern a Score.
+type URLPattern struct {
+	Pattern *regexp.Regexp
+	Score   float64
+}
+
+// PatternOrder is a best-first ordering: a URL scores the sum of the
+// patterns it matches, so e.g. /docs/ can be crawled before /blog/.
+type PatternOrder []URLPattern
+
+func (o PatternOrder) Score(entry FrontierEntry) float64 {
+	score := 0.0
+	for _, pattern := range o {
+		if pattern.Pattern.MatchString(entry.URL) {
+			score += pattern.Score
+		}
+	}
+	return score
+}
+
+// ParseURLPatterns parses "regexp=score" specs into a PatternOrder. The
+// score is optional and defaults to 1; negative scores push URLs back.
+func ParseURLPatterns(specs []string) (PatternOrder, error) {
+	var order PatternOrder
+	for _, spec := range specs {
+		expr, score := spec, 1.0
+		if i := strings.LastIndex(spec, "="); i >= 0 {
+			if parsed, err := strconv.ParseFloat(spec[i+1:], 64); err == nil {
+				expr, score = spec[:i], parsed
+			}
+		}
+
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %v", expr, err)
+		}
+		order = append(order, URLPattern{Pattern: pattern, Score: score})
+	}
+	return order, nil
+}
+
+// ParseOrdering returns the ordering called name: bfs, dfs, best-first
+// (scored by patterns, see ParseURLPatterns) or sitemap.
+func ParseOrdering(name string, patterns []string) (Ordering, error) {
+	switch strings.ToLower(name) {
+	case "", "bfs":
+		return BreadthFirst, nil
+	case "dfs":
+		return DepthFirst, nil
+	case "best-first":
+		if len(patterns) == 0 {
+			return nil, fmt.Errorf("best-first ordering needs at least one URL pattern")
+		}
+		return ParseURLPatterns(patterns)
+	case "sitemap":
+		return NewSitemapOrder(), nil
+	default:
+		return nil, fmt.Errorf("unknown ordering %q: use bfs, dfs, best-first or sitemap", name)
+	}
+}
+
+// Frontier holds the URLs waiting to be scraped, in one priority queue per
+// host. Taking work from the best host that is ready, instead of from a
+// single queue, keeps a crawl busy while one host is being waited on. It is
+// safe for concurrent use.
+type Frontier struct {
+	ordering Ordering
+	mutex    sync.Mutex
+	hosts    map[string]*frontierQueue
+	sequence int
+	size     int
+}
+
+type frontierItem struct {
+	entry    FrontierEntry
+	score    float64
+	sequence int
+}
+
+// before reports whether item should be scraped before other.
+func (item *frontierItem) before(other *frontierItem) bool {
+	if item.score != other.score {
+		return item.score > other.score
+	}
+	return item.sequence < other.sequence
+}
+
+// frontierQueue is a heap of items, best first.
+type frontierQueue []*frontierItem
+
+func (q frontierQueue) Len() int            { return len(q) }
+func (q frontierQueue) Less(i, j int) bool  { return q[i].before(q[j]) }
+func (q frontierQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *frontierQueue) Push(x interface{}) { *q = append(*q, x.(*frontierItem)) }
+func (q *frontierQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// NewFrontier returns an empty frontier. A nil ordering means BreadthFirst.
+func NewFrontier(ordering Ordering) *Frontier {
+	if ordering == nil {
+		ordering = BreadthFirst
+	}
+	return &Frontier{
+		ordering: ordering,
+		hosts:    make(map[string]*frontierQueue),
+	}
+}
+
+func (f *Frontier) Push(entry FrontierEntry) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	host := entryHost(entry.URL)
+	queue, exists := f.hosts[host]
+	if !exists {
+		queue = &frontierQueue{}
+		f.hosts[host] = queue
+	}
+
+	f.sequence++
+	heap.Push(queue, &frontierItem{entry: entry, score: f.ordering.Score(entry), sequence: f.sequence})
+	f.size++
+}
+
+// Pop removes and returns the best entry whose host is ready. ready is asked
+// about hosts from the best head entry down, and may book the host when it
+// says yes. ok is false if no host is ready or the frontier is empty.
+func (f *Frontier) Pop(ready func(host string) bool) (entry FrontierEntry, ok bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	hosts := make([]string, 0, len(f.hosts))
+	for host := range f.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return (*f.hosts[hosts[i]])[0].before((*f.hosts[hosts[j]])[0])
+	})
+
+	for _, host := range hosts {
+		if ready != nil && !ready(host) {
+			continue
+		}
+
+		queue := f.hosts[host]
+		item := heap.Pop(queue).(*frontierItem)
+		if queue.Len() == 0 {
+			delete(f.hosts, host)
+		}
+		f.size--
+		return item.entry, true
+	}
+	return FrontierEntry{}, false
+}
+
+func (f *Frontier) Len() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.size
+}
+
+// Hosts returns the hosts that have queued entries.
+func (f *Frontier) Hosts() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	hosts := make([]string, 0, len(f.hosts))
+	for host := range f.hosts {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// Entries returns every queued entry in the order they were pushed, for
+// checkpoints.
+func (f *Frontier) Entries() []FrontierEntry {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var items []*frontierItem
+	for _, queue := range f.hosts {
+		items = append(items, *queue...)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].sequence < items[j].sequence
+	})
+
+	entries := make([]FrontierEntry, len(items))
+	for i, item := range items {
+		entries[i] = item.entry
+	}
+	return entries
+}
+
+func entryHost(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsedURL.Host
+}
@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFrontierOrdering(t *testing.T) {
+	entries := []FrontierEntry{
+		{URL: "https://a.example/", Depth: 0},
+		{URL: "https://a.example/blog/post", Depth: 2},
+		{URL: "https://a.example/docs/intro", Depth: 1},
+		{URL: "https://a.example/blog/", Depth: 1},
+		{URL: "https://a.example/docs/api/ref", Depth: 2},
+	}
+	sitemap := NewSitemapOrder()
+	sitemap.Set("https://a.example/blog/", 0.9)
+	sitemap.Set("https://a.example/docs/api/ref", 0.5)
+
+	tests := []struct {
+		name     string
+		ordering Ordering
+		want     []string // paths, in the order they are popped
+	}{
+		{"nil is breadth-first", nil, []string{"/", "/docs/intro", "/blog/", "/blog/post", "/docs/api/ref"}},
+		{"breadth-first", BreadthFirst, []string{"/", "/docs/intro", "/blog/", "/blog/post", "/docs/api/ref"}},
+		{"depth-first", DepthFirst, []string{"/blog/post", "/docs/api/ref", "/docs/intro", "/blog/", "/"}},
+		{"best-first", mustParseURLPatterns(t, "/docs/=2", "/api/", "/blog/=-1"), []string{"/docs/api/ref", "/docs/intro", "/", "/blog/post", "/blog/"}},
+		{"sitemap", sitemap, []string{"/blog/", "/docs/api/ref", "/", "/blog/post", "/docs/intro"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frontier := NewFrontier(test.ordering)
+			for _, entry := range entries {
+				frontier.Push(entry)
+			}
+			if frontier.Len() != len(entries) {
+				t.Fatalf("Len() = %d, want %d", frontier.Len(), len(entries))
+			}
+
+			var got []string
+			for {
+				entry, ok := frontier.Pop(nil)
+				if !ok {
+					break
+				}
+				got = append(got, strings.TrimPrefix(entry.URL, "https://a.example"))
+			}
+			if strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("popped %v, want %v", got, test.want)
+			}
+			if frontier.Len() != 0 {
+				t.Errorf("Len() = %d after popping everything", frontier.Len())
+			}
+		})
+	}
+}
+
+func mustParseURLPatterns(t *testing.T, specs ...string) PatternOrder {
+	t.Helper()
+	order, err := ParseURLPatterns(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestFrontierSkipsHostsThatAreNotReady(t *testing.T) {
+	frontier := NewFrontier(BreadthFirst)
+	frontier.Push(FrontierEntry{URL: "https://a.example/", Depth: 0})
+	frontier.Push(FrontierEntry{URL: "https://a.example/next", Depth: 1})
+	frontier.Push(FrontierEntry{URL: "https://b.example/deep", Depth: 3})
+
+	// The best entry is on a.example, which has to wait
+	var asked []string
+	entry, ok := frontier.Pop(func(host string) bool {
+		asked = append(asked, host)
+		return host != "a.example"
+	})
+	if !ok || entry.URL != "https://b.example/deep" {
+		t.Fatalf("Pop() = %v, %t, want the b.example entry", entry, ok)
+	}
+	if strings.Join(asked, " ") != "a.example b.example" {
+		t.Errorf("asked about %v, want the best host first", asked)
+	}
+
+	if _, ok := frontier.Pop(func(string) bool { return false }); ok {
+		t.Error("Pop() returned an entry although no host was ready")
+	}
+	if hosts := frontier.Hosts(); len(hosts) != 1 || hosts[0] != "a.example" {
+		t.Errorf("Hosts() = %v, want [a.example]", hosts)
+	}
+
+	// Checkpoints keep the push order
+	var urls []string
+	for _, entry := range frontier.Entries() {
+		urls = append(urls, entry.URL)
+	}
+	if strings.Join(urls, " ") != "https://a.example/ https://a.example/next" {
+		t.Errorf("Entries() = %v", urls)
+	}
+}
+
+func TestParseOrdering(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		wantErr  bool
+	}{
+		{"", nil, false},
+		{"BFS", nil, false},
+		{"dfs", nil, false},
+		{"sitemap", nil, false},
+		{"best-first", []string{`/docs/=2`}, false},
+		{"best-first", nil, true},
+		{"best-first", []string{`(=1`}, true},
+		{"random", nil, true},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseOrdering(test.name, test.patterns); (err != nil) != test.wantErr {
+			t.Errorf("ParseOrdering(%q, %v) error = %v, want error %t", test.name, test.patterns, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	FRONT_MATTER_YAML = "yaml"
+	FRONT_MATTER_TOML = "toml"
+)
+
+// frontMatterField is one key of a page's front matter. Value is a string,
+// []string, int or time.Time.
+type frontMatterField struct {
+	Key   string
+	Value interface{}
+}
+
+// frontMatterFields lists the page's metadata in a stable order, leaving out
+// whatever the page doesn't have.
+func frontMatterFields(page *ScrapedPage) []frontMatterField {
+	fields := []frontMatterField{
+		{"title", page.Title},
+		{"url", page.URL},
+	}
+	add := func(key string, value string) {
+		if value != "" {
+			fields = append(fields, frontMatterField{key, value})
+		}
+	}
+	addTime := func(key string, value *time.Time) {
+		if value != nil {
+			fields = append(fields, frontMatterField{key, *value})
+		}
+	}
+
+	if meta := page.Metadata; meta != nil {
+		add("description", meta.Description)
+		add("author", meta.Author)
+		if len(meta.Keywords) > 0 {
+			fields = append(fields, frontMatterField{"keywords", meta.Keywords})
+		}
+		add("image", meta.Image)
+		add("canonical", meta.Canonical)
+		add("language", meta.Language)
+		addTime("published", meta.Published)
+		addTime("modified", meta.Modified)
+	}
+	fields = append(fields, frontMatterField{"depth", page.Depth})
+	add("content_hash", page.ContentHash)
+	addTime("fetched_at", page.FetchedAt)
+	return fields
+}
+
+// FrontMatter renders the page's metadata as a front matter block in format
+// (FRONT_MATTER_YAML or FRONT_MATTER_TOML), delimiters included, ready to be
+// put in front of the page's Markdown.
+func FrontMatter(page *ScrapedPage, format string) (string, error) {
+	fields := frontMatterFields(page)
+
+	switch format {
+	case FRONT_MATTER_YAML:
+		var values yaml.MapSlice
+		for _, field := range fields {
+			value := field.Value
+			if t, ok := value.(time.Time); ok {
+				value = t.Format(time.RFC3339)
+			}
+			values = append(values, yaml.MapItem{Key: field.Key, Value: value})
+		}
+		data, err := yaml.Marshal(values)
+		if err != nil {
+			return "", err
+		}
+		return "---\n" + string(data) + "---\n", nil
+
+	case FRONT_MATTER_TOML:
+		var content strings.Builder
+		content.WriteString("+++\n")
+		for _, field := range fields {
+			content.WriteString(field.Key + " = " + tomlValue(field.Value) + "\n")
+		}
+		content.WriteString("+++\n")
+		return content.String(), nil
+
+	default:
+		return "", fmt.Errorf("unknown front matter format %q: use yaml or toml", format)
+	}
+}
+
+func tomlValue(value interface{}) string {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case time.Time:
+		return v.Format(time.RFC3339) // a TOML offset date-time
+	case []string:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = tomlValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		// JSON strings are valid TOML basic strings
+		var data bytes.Buffer
+		encoder := json.NewEncoder(&data)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(fmt.Sprint(v))
+		return strings.TrimSuffix(data.String(), "\n")
+	}
+}
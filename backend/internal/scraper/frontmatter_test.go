@@ -0,0 +1,97 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+func frontMatterTestPage() *ScrapedPage {
+	published := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	fetched := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	return &ScrapedPage{
+		URL:   "https://example.com/guide",
+		Title: `Guide: "getting started"`,
+		Depth: 1,
+		Metadata: &Metadata{
+			Description: "How to start # without a comment",
+			Keywords:    []string{"setup", "install"},
+			Canonical:   "https://example.com/guide",
+			Language:    "en",
+			Published:   &published,
+		},
+		ContentHash: "abc123",
+		FetchedAt:   &fetched,
+	}
+}
+
+func TestFrontMatterYAML(t *testing.T) {
+	block, err := FrontMatter(frontMatterTestPage(), FRONT_MATTER_YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(block, "---\ntitle:") || !strings.HasSuffix(block, "\n---\n") {
+		t.Fatalf("front matter =\n%s\nwant a --- block starting with the title", block)
+	}
+
+	var fields struct {
+		Title       string   `yaml:"title"`
+		URL         string   `yaml:"url"`
+		Description string   `yaml:"description"`
+		Keywords    []string `yaml:"keywords"`
+		Published   string   `yaml:"published"`
+		Depth       int      `yaml:"depth"`
+		FetchedAt   string   `yaml:"fetched_at"`
+		Author      *string  `yaml:"author"`
+	}
+	if err := yaml.Unmarshal([]byte(strings.Trim(block, "-\n")), &fields); err != nil {
+		t.Fatalf("front matter isn't valid YAML: %v\n%s", err, block)
+	}
+	if fields.Title != `Guide: "getting started"` || fields.Description != "How to start # without a comment" {
+		t.Errorf("title and description = %q, %q, want them unchanged", fields.Title, fields.Description)
+	}
+	if fields.URL != "https://example.com/guide" || fields.Depth != 1 || strings.Join(fields.Keywords, ",") != "setup,install" {
+		t.Errorf("fields = %+v", fields)
+	}
+	if fields.Published != "2026-03-01T09:30:00Z" || fields.FetchedAt != "2026-03-02T12:00:00Z" {
+		t.Errorf("dates = %s, %s, want RFC 3339", fields.Published, fields.FetchedAt)
+	}
+	if fields.Author != nil {
+		t.Error("the page has no author, but the front matter does")
+	}
+}
+
+func TestFrontMatterTOML(t *testing.T) {
+	block, err := FrontMatter(frontMatterTestPage(), FRONT_MATTER_TOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `+++
+title = "Guide: \"getting started\""
+url = "https://example.com/guide"
+description = "How to start # without a comment"
+keywords = ["setup", "install"]
+canonical = "https://example.com/guide"
+language = "en"
+published = 2026-03-01T09:30:00Z
+depth = 1
+content_hash = "abc123"
+fetched_at = 2026-03-02T12:00:00Z
++++
+`
+	if block != want {
+		t.Errorf("front matter =\n%s\nwant\n%s", block, want)
+	}
+
+	// A page without metadata still has its title, URL and depth
+	block, _ = FrontMatter(&ScrapedPage{URL: "https://example.com/", Title: "Home"}, FRONT_MATTER_TOML)
+	if block != "+++\ntitle = \"Home\"\nurl = \"https://example.com/\"\ndepth = 0\n+++\n" {
+		t.Errorf("front matter without metadata =\n%s", block)
+	}
+
+	if _, err := FrontMatter(frontMatterTestPage(), "json"); err == nil {
+		t.Error("FrontMatter() accepted an unknown format")
+	}
+}
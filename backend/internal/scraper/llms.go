@@ -0,0 +1,253 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	LLMS_TXT      = "llms.txt"
+	LLMS_FULL_TXT = "llms-full.txt"
+
+	MAX_LLMS_DESCRIPTION = 160 // characters
+	LLMS_ROOT_SECTION    = "Pages"
+)
+
+// llmsSection is a "## Section" of llms.txt and the pages listed in it.
+type llmsSection struct {
+	name  string
+	pages []*ScrapedPage
+}
+
+// LLMsTxt renders the pages of one seed as an llms.txt index
+// (https://llmstxt.org): the site title and summary, then the pages as
+// links with a one-line description, sectioned by their breadcrumbs or,
+// failing that, the first directory of their path below the seed.
+func LLMsTxt(seed string, pages []*ScrapedPage) string {
+	var content strings.Builder
+	home := writeLLMsHeader(&content, seed, pages)
+
+	for _, section := range llmsSections(seed, pages) {
+		// The header already stands for the home page
+		if len(section.pages) == 1 && section.pages[0] == home {
+			continue
+		}
+		content.WriteString(fmt.Sprintf("## %s\n\n", section.name))
+		for _, page := range section.pages {
+			if page == home {
+				continue
+			}
+			content.WriteString(fmt.Sprintf("- [%s](%s)", llmsLinkText(page.Title), page.URL))
+			if description := pageDescription(page); description != "" {
+				content.WriteString(": " + description)
+			}
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+
+	return strings.TrimRight(content.String(), "\n") + "\n"
+}
+
+// LLMsFullTxt renders the pages of one seed as llms-full.txt: the same
+// header as llms.txt followed by the content of every page, in the same
+// order as the index.
+func LLMsFullTxt(seed string, pages []*ScrapedPage) string {
+	var content strings.Builder
+	writeLLMsHeader(&content, seed, pages)
+
+	for _, section := range llmsSections(seed, pages) {
+		for _, page := range section.pages {
+			content.WriteString("---\n\n")
+			content.WriteString(fmt.Sprintf("# %s\n\n", page.Title))
+			content.WriteString(fmt.Sprintf("Source: %s\n\n", page.URL))
+			content.WriteString(strings.TrimSpace(InlineLinkReferences(page.Markdown)))
+			content.WriteString("\n\n")
+		}
+	}
+
+	return strings.TrimRight(content.String(), "\n") + "\n"
+}
+
+// writeLLMsHeader writes the title and summary, taken from the home page,
+// which it returns.
+func writeLLMsHeader(content *strings.Builder, seed string, pages []*ScrapedPage) *ScrapedPage {
+	home := llmsHomePage(seed, pages)
+
+	title := seed
+	var summary string
+	if home != nil {
+		title = home.Title
+		if meta := home.Metadata; meta != nil {
+			if meta.OpenGraph != nil && meta.OpenGraph.SiteName != "" {
+				title = meta.OpenGraph.SiteName
+			}
+		}
+		summary = pageDescription(home)
+	}
+
+	content.WriteString(fmt.Sprintf("# %s\n\n", title))
+	if summary != "" {
+		content.WriteString(fmt.Sprintf("> %s\n\n", summary))
+	}
+	return home
+}
+
+// llmsHomePage returns the seed's own page, or the shallowest page if the
+// seed redirected or failed.
+func llmsHomePage(seed string, pages []*ScrapedPage) *ScrapedPage {
+	var home *ScrapedPage
+	for _, page := range pages {
+		if page.Error != "" {
+			continue
+		}
+		if page.URL == seed {
+			return page
+		}
+		if home == nil || page.Depth < home.Depth {
+			home = page
+		}
+	}
+	return home
+}
+
+// llmsSections groups the successful pages by section. Pages are sorted by
+// depth and URL, so the output doesn't depend on the order pages finished
+// in, and sections come in the order their first page does.
+func llmsSections(seed string, pages []*ScrapedPage) []llmsSection {
+	var sorted []*ScrapedPage
+	for _, page := range pages {
+		if page.Error == "" {
+			sorted = append(sorted, page)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Depth != sorted[j].Depth {
+			return sorted[i].Depth < sorted[j].Depth
+		}
+		return sorted[i].URL < sorted[j].URL
+	})
+
+	var sections []llmsSection
+	index := make(map[string]int)
+	for _, page := range sorted {
+		name := llmsSectionName(seed, page)
+		i, exists := index[name]
+		if !exists {
+			i = len(sections)
+			index[name] = i
+			sections = append(sections, llmsSection{name: name})
+		}
+		sections[i].pages = append(sections[i].pages, page)
+	}
+
+	// Top-level pages come first, next to the home page
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].name == LLMS_ROOT_SECTION && sections[j].name != LLMS_ROOT_SECTION
+	})
+	return sections
+}
+
+// llmsSectionName picks the section of a page: the breadcrumb below the
+// home crumb if the page has a BreadcrumbList, else its first directory
+// below the seed's path.
+func llmsSectionName(seed string, page *ScrapedPage) string {
+	if meta := page.Metadata; meta != nil && meta.Structured != nil {
+		// Home > Section > ... > Page
+		if crumbs := meta.Structured.Breadcrumbs; len(crumbs) >= 3 {
+			return crumbs[1].Name
+		}
+	}
+
+	pageURL, err := url.Parse(page.URL)
+	if err != nil {
+		return LLMS_ROOT_SECTION
+	}
+	seedPath := ""
+	if seedURL, err := url.Parse(seed); err == nil {
+		seedPath = seedURL.Path
+		// A seed like /docs/index.html scopes to /docs/
+		if !strings.HasSuffix(seedPath, "/") {
+			seedPath = seedPath[:strings.LastIndex(seedPath, "/")+1]
+		}
+	}
+
+	rest := strings.TrimPrefix(pageURL.Path, seedPath)
+	directory, _, isNested := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+	if !isNested || directory == "" {
+		return LLMS_ROOT_SECTION
+	}
+	return sectionTitle(directory)
+}
+
+// sectionTitle turns a path segment into a heading: getting-started ->
+// Getting started.
+func sectionTitle(segment string) string {
+	if unescaped, err := url.PathUnescape(segment); err == nil {
+		segment = unescaped
+	}
+	segment = strings.NewReplacer("-", " ", "_", " ").Replace(segment)
+	if segment == "" {
+		return LLMS_ROOT_SECTION
+	}
+	first, size := utf8.DecodeRuneInString(segment)
+	return strings.ToUpper(string(first)) + segment[size:]
+}
+
+var (
+	markdownImagePattern    = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	markdownEmphasisPattern = regexp.MustCompile("[*_`]+")
+)
+
+// pageDescription returns the page's meta description, or else its first
+// paragraph of prose, on one line and at most MAX_LLMS_DESCRIPTION
+// characters long.
+func pageDescription(page *ScrapedPage) string {
+	if page.Metadata != nil && page.Metadata.Description != "" {
+		return truncateDescription(strings.Join(strings.Fields(page.Metadata.Description), " "))
+	}
+
+	for _, paragraph := range splitParagraphs(InlineLinkReferences(page.Markdown)) {
+		text := strings.TrimSpace(paragraph)
+		if text == "" || strings.ContainsAny(text[:1], "#|>-*+!`~[") || isStructuralBlock(text) {
+			continue // headings, tables, quotes, lists, images, code and nav links
+		}
+		text = markdownImagePattern.ReplaceAllString(text, "")
+		text = markdownLinkPattern.ReplaceAllString(text, "$1")
+		text = markdownEmphasisPattern.ReplaceAllString(text, "")
+		text = strings.Join(strings.Fields(text), " ")
+		// Skip stray lines such as the page title above its heading
+		if len(strings.Fields(text)) < 5 {
+			continue
+		}
+		return truncateDescription(text)
+	}
+	return ""
+}
+
+// truncateDescription cuts text to MAX_LLMS_DESCRIPTION characters, at the
+// end of a sentence if there is one, else at a word.
+func truncateDescription(text string) string {
+	if utf8.RuneCountInString(text) <= MAX_LLMS_DESCRIPTION {
+		return text
+	}
+	runes := []rune(text)[:MAX_LLMS_DESCRIPTION]
+	cut := string(runes)
+	if end := strings.LastIndex(cut, ". "); end > MAX_LLMS_DESCRIPTION/2 {
+		return cut[:end+1]
+	}
+	if space := strings.LastIndex(cut, " "); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, ",;:") + "…"
+}
+
+// llmsLinkText keeps a title from breaking the Markdown link around it.
+func llmsLinkText(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(title)
+}
@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func llmsTestPages() []*ScrapedPage {
+	long := strings.Repeat("The installer checks the system first. ", 3) + strings.Repeat("It then copies every file into place and writes the configuration ", 3)
+	return []*ScrapedPage{
+		{URL: "https://example.com/docs/getting-started/install", Title: "Install", Depth: 2,
+			Markdown: "# Install\n\n" + long},
+		{URL: "https://example.com/", Title: "Home", Depth: 0,
+			Markdown: "# Home\n\nWelcome.",
+			Metadata: &Metadata{Description: "Tools for   converting websites.", OpenGraph: &OpenGraph{SiteName: "Example"}}},
+		{URL: "https://example.com/about", Title: "About [us]", Depth: 1,
+			Markdown: "# About\n\n- [Home](/)\n\nWe are a *small* team building [tools](https://example.com/tools) for docs."},
+		{URL: "https://example.com/blog/2026/release", Title: "Release", Depth: 1,
+			Markdown: "# Release\n\nShort.",
+			Metadata: &Metadata{Structured: &StructuredData{Breadcrumbs: []Breadcrumb{{Name: "Home"}, {Name: "News"}, {Name: "Release"}}}}},
+		{URL: "https://example.com/docs/faq", Title: "FAQ", Depth: 1,
+			Markdown: "# FAQ\n\nAnswers to the questions people ask most often."},
+		{URL: "https://example.com/broken", Title: "Broken", Depth: 1, Error: "HTTP 500"},
+	}
+}
+
+func TestLLMsTxt(t *testing.T) {
+	got := LLMsTxt("https://example.com/", llmsTestPages())
+	want := `# Example
+
+> Tools for converting websites.
+
+## Pages
+
+- [About \[us\]](https://example.com/about): We are a small team building tools for docs.
+
+## News
+
+- [Release](https://example.com/blog/2026/release)
+
+## Docs
+
+- [FAQ](https://example.com/docs/faq): Answers to the questions people ask most often.
+- [Install](https://example.com/docs/getting-started/install): The installer checks the system first. The installer checks the system first. The installer checks the system first.
+`
+	if got != want {
+		t.Errorf("LLMsTxt() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLLMsFullTxt(t *testing.T) {
+	got := LLMsFullTxt("https://example.com/", llmsTestPages())
+	if !strings.HasPrefix(got, "# Example\n\n> Tools for converting websites.\n\n---\n\n# Home\n\nSource: https://example.com/\n\n# Home\n\nWelcome.\n\n") {
+		t.Errorf("LLMsFullTxt() starts with\n%s\nwant the header, then the home page", got[:min(len(got), 200)])
+	}
+
+	// Every successful page once, in the order of the index
+	var sources []string
+	for _, line := range strings.Split(got, "\n") {
+		if strings.HasPrefix(line, "Source: ") {
+			sources = append(sources, strings.TrimPrefix(line, "Source: https://example.com"))
+		}
+	}
+	if strings.Join(sources, " ") != "/ /about /blog/2026/release /docs/faq /docs/getting-started/install" {
+		t.Errorf("pages in llms-full.txt = %v", sources)
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	words := strings.Repeat("word ", 60)
+	got := truncateDescription(words)
+	if !strings.HasSuffix(got, "word…") || utf8.RuneCountInString(got) > MAX_LLMS_DESCRIPTION+1 {
+		t.Errorf("truncateDescription() = %q, want it cut at a word", got)
+	}
+	if short := "A short description."; truncateDescription(short) != short {
+		t.Errorf("truncateDescription(%q) changed it", short)
+	}
+}
@@ -0,0 +1,232 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Metadata is what a page says about itself in its markup: meta tags,
+// OpenGraph and Twitter cards, and JSON-LD structured data. The top-level
+// fields take the first value found across those sources.
+type Metadata struct {
+	Description string     `json:"description,omitempty"`
+	Keywords    []string   `json:"keywords,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	Image       string     `json:"image,omitempty"`
+	Canonical   string     `json:"canonical,omitempty"`
+	Language    string     `json:"language,omitempty"`
+	Published   *time.Time `json:"published,omitempty"`
+	Modified    *time.Time `json:"modified,omitempty"`
+
+	OpenGraph  *OpenGraph      `json:"openGraph,omitempty"`
+	Twitter    *TwitterCard    `json:"twitter,omitempty"`
+	Structured *StructuredData `json:"structured,omitempty"` // from JSON-LD
+}
+
+// OpenGraph holds the page's og:* properties.
+type OpenGraph struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Image       string `json:"image,omitempty"`
+	SiteName    string `json:"siteName,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+}
+
+// TwitterCard holds the page's twitter:* meta tags.
+type TwitterCard struct {
+	Card        string `json:"card,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	Site        string `json:"site,omitempty"`
+	Creator     string `json:"creator,omitempty"`
+}
+
+// Date layouts seen in the wild for published/modified times
+var metadataDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// extractMetadata reads the page's meta tags and structured data. baseURL
+// resolves relative links such as the canonical URL and images.
+func extractMetadata(doc *goquery.Document, baseURL string) *Metadata {
+	meta := &Metadata{
+		Description: metaContent(doc, `meta[name="description"]`),
+		Author:      metaContent(doc, `meta[name="author"]`, `meta[property="article:author"]`),
+		Language:    strings.TrimSpace(doc.Find("html").AttrOr("lang", "")),
+		Published: parseMetadataDate(metaContent(doc,
+			`meta[property="article:published_time"]`, `meta[itemprop="datePublished"]`, `meta[name="date"]`)),
+		Modified: parseMetadataDate(metaContent(doc,
+			`meta[property="article:modified_time"]`, `meta[itemprop="dateModified"]`, `meta[name="last-modified"]`)),
+		OpenGraph:  extractOpenGraph(doc, baseURL),
+		Twitter:    extractTwitterCard(doc, baseURL),
+		Structured: extractStructuredData(doc, baseURL),
+	}
+
+	for _, keyword := range strings.Split(metaContent(doc, `meta[name="keywords"]`), ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			meta.Keywords = append(meta.Keywords, keyword)
+		}
+	}
+
+	if meta.Language == "" {
+		meta.Language = metaContent(doc, `meta[http-equiv="content-language"]`)
+	}
+
+	if canonical, exists := doc.Find(`link[rel="canonical"]`).First().Attr("href"); exists && canonical != "" {
+		meta.Canonical = resolveURL(baseURL, strings.TrimSpace(canonical))
+	}
+
+	// Fill the gaps from the social cards and structured data
+	if og := meta.OpenGraph; og != nil {
+		meta.Description = firstNonEmpty(meta.Description, og.Description)
+		meta.Image = firstNonEmpty(meta.Image, og.Image)
+		meta.Canonical = firstNonEmpty(meta.Canonical, og.URL)
+	}
+	if card := meta.Twitter; card != nil {
+		meta.Description = firstNonEmpty(meta.Description, card.Description)
+		meta.Image = firstNonEmpty(meta.Image, card.Image)
+	}
+	if data := meta.Structured; data != nil && len(data.Articles) > 0 {
+		article := data.Articles[0]
+		meta.Description = firstNonEmpty(meta.Description, article.Description)
+		meta.Image = firstNonEmpty(meta.Image, article.Image)
+		if meta.Author == "" && len(article.Authors) > 0 {
+			meta.Author = strings.Join(article.Authors, ", ")
+		}
+		if meta.Published == nil {
+			meta.Published = parseMetadataDate(article.DatePublished)
+		}
+		if meta.Modified == nil {
+			meta.Modified = parseMetadataDate(article.DateModified)
+		}
+	}
+
+	if meta.empty() {
+		return nil
+	}
+	return meta
+}
+
+func (m *Metadata) empty() bool {
+	return m.Description == "" && len(m.Keywords) == 0 && m.Author == "" && m.Image == "" &&
+		m.Canonical == "" && m.Language == "" && m.Published == nil && m.Modified == nil &&
+		m.OpenGraph == nil && m.Twitter == nil && m.Structured == nil
+}
+
+func extractOpenGraph(doc *goquery.Document, baseURL string) *OpenGraph {
+	property := func(name string) string {
+		return metaContent(doc, `meta[property="og:`+name+`"]`, `meta[name="og:`+name+`"]`)
+	}
+
+	og := &OpenGraph{
+		Title:       property("title"),
+		Description: property("description"),
+		Type:        property("type"),
+		URL:         resolveOptionalURL(baseURL, property("url")),
+		Image:       resolveOptionalURL(baseURL, firstNonEmpty(property("image"), property("image:url"))),
+		SiteName:    property("site_name"),
+		Locale:      property("locale"),
+	}
+	if *og == (OpenGraph{}) {
+		return nil
+	}
+	return og
+}
+
+func extractTwitterCard(doc *goquery.Document, baseURL string) *TwitterCard {
+	// Twitter tags are meant to use name=, but property= is common too
+	tag := func(name string) string {
+		return metaContent(doc, `meta[name="twitter:`+name+`"]`, `meta[property="twitter:`+name+`"]`)
+	}
+
+	card := &TwitterCard{
+		Card:        tag("card"),
+		Title:       tag("title"),
+		Description: tag("description"),
+		Image:       resolveOptionalURL(baseURL, tag("image")),
+		Site:        tag("site"),
+		Creator:     tag("creator"),
+	}
+	if *card == (TwitterCard{}) {
+		return nil
+	}
+	return card
+}
+
+// metaContent returns the content of the first selector that matches a
+// non-empty meta tag.
+func metaContent(doc *goquery.Document, selectors ...string) string {
+	for _, selector := range selectors {
+		var content string
+		doc.Find(selector).EachWithBreak(func(i int, sel *goquery.Selection) bool {
+			content = strings.TrimSpace(sel.AttrOr("content", ""))
+			return content == ""
+		})
+		if content != "" {
+			return content
+		}
+	}
+	return ""
+}
+
+func parseMetadataDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	for _, layout := range metadataDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
+
+// resolveOptionalURL is resolveURL for values that may be missing.
+func resolveOptionalURL(baseURL, href string) string {
+	if href == "" {
+		return ""
+	}
+	return resolveURL(baseURL, href)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func resolveURL(baseURL, href string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// contentHash identifies a page's Markdown, so unchanged pages can be told
+// apart from updated ones between crawls.
+func contentHash(markdown string) string {
+	sum := sha256.Sum256([]byte(markdown))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
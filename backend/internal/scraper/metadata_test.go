@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const metadataTestPage = `<html lang="en"><head>
+<title>Release notes</title>
+<meta name="keywords" content="release, notes, ,changelog">
+<link rel="canonical" href="/blog/release">
+<meta property="og:title" content="Release notes">
+<meta property="og:description" content="What changed in 2.0">
+<meta property="og:image" content="/img/cover.png">
+<meta property="og:type" content="article">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:creator" content="@docs">
+<script type="application/ld+json">{ not json</script>
+<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [
+  {"@type": "BlogPosting", "headline": "Release 2.0", "author": [{"@type": "Person", "name": "Ada"}, "Grace"],
+   "datePublished": "2026-02-01T08:00:00+01:00", "dateModified": "2026-02-03"},
+  {"@type": "BreadcrumbList", "itemListElement": [
+    {"@type": "ListItem", "position": 2, "name": "Blog", "item": "/blog/"},
+    {"@type": "ListItem", "position": 1, "name": "Home", "item": {"@id": "https://example.com/"}}]},
+  {"@type": "FAQPage", "mainEntity": [{"@type": "Question", "name": "Is it free?",
+   "acceptedAnswer": {"@type": "Answer", "text": "<p>Yes, <b>always</b>.</p>"}}]},
+  {"@type": "Organization", "name": "Example"}
+]}</script>
+</head><body><h1>Release notes</h1>
+<p>The release brings a new crawler, a new converter and many smaller fixes.</p>
+<p>Upgrading is a matter of replacing the binary and restarting the service.</p>
+</body></html>`
+
+func TestExtractMetadata(t *testing.T) {
+	page, err := ConvertHTML(strings.NewReader(metadataTestPage), "https://example.com/blog/release?ref=feed", &ScrapingConfig{Log: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := page.Metadata
+	if meta == nil {
+		t.Fatal("page has no metadata")
+	}
+
+	// The top-level fields fall back to the cards and the JSON-LD article
+	if meta.Description != "What changed in 2.0" || meta.Image != "https://example.com/img/cover.png" || meta.Author != "Ada, Grace" {
+		t.Errorf("description, image, author = %q, %q, %q", meta.Description, meta.Image, meta.Author)
+	}
+	if meta.Canonical != "https://example.com/blog/release" || meta.Language != "en" {
+		t.Errorf("canonical, language = %q, %q", meta.Canonical, meta.Language)
+	}
+	if strings.Join(meta.Keywords, "|") != "release|notes|changelog" {
+		t.Errorf("keywords = %q", meta.Keywords)
+	}
+	if meta.Published == nil || !meta.Published.Equal(time.Date(2026, 2, 1, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("published = %v, want 2026-02-01 07:00 UTC", meta.Published)
+	}
+	if meta.Modified == nil || meta.Modified.Format("2006-01-02") != "2026-02-03" {
+		t.Errorf("modified = %v, want 2026-02-03", meta.Modified)
+	}
+	if meta.OpenGraph == nil || meta.OpenGraph.Type != "article" || meta.Twitter == nil || meta.Twitter.Creator != "@docs" {
+		t.Errorf("cards = %+v, %+v", meta.OpenGraph, meta.Twitter)
+	}
+
+	data := meta.Structured
+	if data == nil || len(data.Articles) != 1 || data.Articles[0].Type != "BlogPosting" || data.Articles[0].Headline != "Release 2.0" {
+		t.Fatalf("structured data = %+v, want the blog post", data)
+	}
+	if len(data.Breadcrumbs) != 2 || data.Breadcrumbs[0].Name != "Home" || data.Breadcrumbs[1].URL != "https://example.com/blog/" {
+		t.Errorf("breadcrumbs = %+v, want Home then Blog, in position order", data.Breadcrumbs)
+	}
+	if len(data.FAQ) != 1 || data.FAQ[0].Question != "Is it free?" || data.FAQ[0].Answer != "Yes, always." {
+		t.Errorf("FAQ = %+v, want the answer as text", data.FAQ)
+	}
+}
+
+func TestExtractMetadataWithoutAny(t *testing.T) {
+	page, err := ConvertHTML(strings.NewReader(warcTestPage), "https://example.com/", &ScrapingConfig{Log: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Metadata != nil {
+		t.Errorf("metadata = %+v, want none for a page without any", page.Metadata)
+	}
+}
@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// politeness is the crawl-wide request budget: at most Concurrency requests
+// in flight, and at least Delay between two requests to the same host. It is
+// shared by every seed of a run, so overlapping seeds don't double the load.
+type politeness struct {
+	slots    chan struct{}
+	delay    time.Duration
+	mutex    sync.Mutex
+	nextSlot map[string]time.Time // host -> earliest time of the next request
+}
+
+func newPoliteness(concurrency int, delay time.Duration) *politeness {
+	if concurrency <= 0 {
+		concurrency = DEFAULT_CONCURRENCY
+	}
+	return &politeness{
+		slots:    make(chan struct{}, concurrency),
+		delay:    delay,
+		nextSlot: make(map[string]time.Time),
+	}
+}
+
+// acquire waits for a free slot and for host's delay to pass. Every
+// successful acquire must be followed by release.
+func (p *politeness) acquire(ctx context.Context, host string) error {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if wait := p.reserve(host); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			p.release()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (p *politeness) release() {
+	<-p.slots
+}
+
+// reserve books the next request slot for host and returns how long to
+// wait for it.
+func (p *politeness) reserve(host string) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	at := p.nextSlot[host]
+	if at.Before(now) {
+		at = now
+	}
+	p.nextSlot[host] = at.Add(p.delay)
+	return at.Sub(now)
+}
+
+// tryReserve books the next request to host if its delay has already
+// passed, and reports whether it did. The crawl scheduler uses it to pick a
+// host that can be fetched right away, and limits requests in flight itself
+// instead of taking slots.
+func (p *politeness) tryReserve(host string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	if p.nextSlot[host].After(now) {
+		return false
+	}
+	p.nextSlot[host] = now.Add(p.delay)
+	return true
+}
+
+// wait returns how long until host may be requested again.
+func (p *politeness) wait(host string) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return time.Until(p.nextSlot[host])
+}
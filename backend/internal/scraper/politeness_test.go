@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPolitenessReserve(t *testing.T) {
+	const delay = time.Hour
+	p := newPoliteness(1, delay)
+
+	// Slots for one host are booked a delay apart
+	for i, want := range []time.Duration{0, delay, 2 * delay} {
+		if wait := p.reserve("a.example"); wait > want || want-wait >= time.Minute {
+			t.Errorf("reserve() #%d = %v, want about %v", i+1, wait, want)
+		}
+	}
+	// Other hosts don't wait for it
+	if wait := p.reserve("b.example"); wait != 0 {
+		t.Errorf("reserve() for another host = %v, want 0", wait)
+	}
+}
+
+func TestPolitenessAcquire(t *testing.T) {
+	p := newPoliteness(1, time.Hour)
+
+	if err := p.acquire(context.Background(), "a.example"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The only slot is taken
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.acquire(ctx, "b.example"); err == nil {
+		t.Fatal("acquire() succeeded without a free slot")
+	}
+	p.release()
+
+	// The slot is free, but a.example has to wait for its delay
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.acquire(ctx, "a.example"); err == nil {
+		t.Fatal("acquire() didn't wait for the host's delay")
+	}
+	// A cancelled wait gives its slot back
+	if err := p.acquire(context.Background(), "c.example"); err != nil {
+		t.Fatal(err)
+	}
+	p.release()
+}
+
+func TestPolitenessTryReserve(t *testing.T) {
+	const delay = time.Hour
+	p := newPoliteness(1, delay)
+
+	if !p.tryReserve("a.example") {
+		t.Fatal("tryReserve() refused a host that was never requested")
+	}
+	if p.tryReserve("a.example") {
+		t.Error("tryReserve() booked a host within its delay")
+	}
+	if wait := p.wait("a.example"); wait > delay || delay-wait >= time.Minute {
+		t.Errorf("wait() = %v, want about %v", wait, delay)
+	}
+	if !p.tryReserve("b.example") {
+		t.Error("tryReserve() held up another host")
+	}
+	if wait := p.wait("c.example"); wait > 0 {
+		t.Errorf("wait() = %v for a new host, want none", wait)
+	}
+}
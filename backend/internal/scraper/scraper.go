@@ -1,9 +1,12 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"regexp"
 	"strings"
 	"sync"
@@ -19,24 +22,90 @@ type ScrapingConfig struct {
 	FollowExternal bool          `json:"followExternal"`
 	UserAgent      string        `json:"userAgent"`
 	Concurrency    int           `json:"concurrency"`
+	MaxPages       int           `json:"maxPages,omitempty"` // 0 means unlimited
+
+	// MaxTokens stops the crawl once the pages add up to this many tokens
+	// (0 means unlimited). Tokenizer counts them; it defaults to
+	// ApproximateTokenizer.
+	MaxTokens int       `json:"maxTokens,omitempty"`
+	Tokenizer Tokenizer `json:"-"`
+
+	// Duplicates decides what happens to pages whose content is the same
+	// as, or at least DuplicateThreshold similar to, a page scraped before:
+	// DUPLICATES_OFF (default), DUPLICATES_GROUP or DUPLICATES_DROP.
+	Duplicates         string  `json:"duplicates,omitempty"`
+	DuplicateThreshold float64 `json:"duplicateThreshold,omitempty"`
+
+	// Converter sets the Markdown style and extra element rules; see
+	// RegisterRule for rules shared by every scraper.
+	Converter ConverterOptions `json:"converter"`
+
+	// BlockPrivateNetworks refuses to fetch loopback, private, link-local and
+	// metadata addresses (SSRF protection). AllowedHosts lists exceptions.
+	BlockPrivateNetworks bool     `json:"blockPrivateNetworks,omitempty"`
+	AllowedHosts         []string `json:"allowedHosts,omitempty"`
+
+	// Fetcher retrieves pages. Defaults to an HTTPFetcher; see FileFetcher,
+	// MemoryFetcher and SchemeFetcher for the built-in alternatives. A custom
+	// Fetcher bypasses the BlockPrivateNetworks transport (seeds are still
+	// checked).
+	Fetcher Fetcher `json:"-"`
+	// WARC, if set, records every HTTP request and response of the default
+	// Fetcher. Custom HTTPFetchers can record with RecordTo.
+	WARC *WARCWriter `json:"-"`
+
+	// Ordering decides which queued URL is scraped next. Defaults to
+	// BreadthFirst; see DepthFirst, PatternOrder and SitemapOrder. Order and
+	// Prefer name it for ParseOrdering, so checkpoints can rebuild it; if
+	// Ordering is nil it is built from them.
+	Ordering Ordering `json:"-"`
+	Order    string   `json:"order,omitempty"`
+	Prefer   []string `json:"prefer,omitempty"`
+
+	// Checkpoint, if set, is called with the crawl state every
+	// CheckpointInterval and when the crawl is interrupted, so it can be
+	// continued later with Resume.
+	Checkpoint         func(state *CrawlState) error `json:"-"`
+	CheckpointInterval time.Duration                 `json:"-"`
+
+	// Log receives progress messages. Defaults to os.Stdout.
+	Log io.Writer `json:"-"`
 }
 
 type ScrapedPage struct {
-	URL      string `json:"url"`
-	Title    string `json:"title"`
-	Markdown string `json:"markdown"`
-	Depth    int    `json:"depth"`
-	Error    string `json:"error,omitempty"`
+	URL         string     `json:"url"`
+	Title       string     `json:"title"`
+	Markdown    string     `json:"markdown"`
+	Seed        string     `json:"seed,omitempty"` // start URL this page was reached from
+	Depth       int        `json:"depth"`
+	Metadata    *Metadata  `json:"metadata,omitempty"`
+	ContentHash string     `json:"contentHash,omitempty"` // sha256 of Markdown
+	Fingerprint string     `json:"fingerprint,omitempty"` // SimHash of Markdown, for near-duplicates
+	DuplicateOf string     `json:"duplicateOf,omitempty"` // first page with the same content
+	FetchedAt   *time.Time `json:"fetchedAt,omitempty"`
+	TextSize               // of Markdown
+	Warnings    []string   `json:"warnings,omitempty"` // parts of the page that didn't convert faithfully
+	Error       string     `json:"error,omitempty"`
 }
 
 type Scraper struct {
 	config         ScrapingConfig
 	visited        map[string]bool
 	visitedMutex   sync.RWMutex
-	baseHost       string
+	seedHosts      map[string]string // seed URL -> host that defines its scope
+	seeds          []string          // seed URLs in the order they were added
 	converter      *md.Converter
-	client         *http.Client
+	fetcher        Fetcher
+	guard          *NetworkGuard
+	polite         *politeness
+	frontier       *Frontier
 	duplicateCount int
+	pageCount      int
+	tokenCount     int
+	dedupe         *duplicateDetector // nil unless Duplicates is set
+	droppedCount   int                // near-duplicates left out
+	completed      []string           // URLs that are fully handled, for checkpoints
+	lastCheckpoint time.Time
 }
 
 const (
@@ -44,6 +113,8 @@ const (
 	DEFAULT_DELAY       = time.Second * 1
 	DEFAULT_USER_AGENT  = "Website-Markdown-Converter/1.0"
 	DEFAULT_CONCURRENCY = 5
+
+	DEFAULT_CHECKPOINT_INTERVAL = 10 * time.Second
 )
 
 func NewScraper(config *ScrapingConfig) *Scraper {
@@ -61,143 +132,358 @@ func NewScraper(config *ScrapingConfig) *Scraper {
 		config.Concurrency = DEFAULT_CONCURRENCY
 	}
 
-	converter := md.NewConverter("", true, nil)
+	if config.Log == nil {
+		config.Log = os.Stdout
+	}
+
+	if config.CheckpointInterval <= 0 {
+		config.CheckpointInterval = DEFAULT_CHECKPOINT_INTERVAL
+	}
+
+	if config.Tokenizer == nil {
+		config.Tokenizer = ApproximateTokenizer
+	}
+
+	if config.DuplicateThreshold <= 0 {
+		config.DuplicateThreshold = DEFAULT_DUPLICATE_THRESHOLD
+	}
+	var dedupe *duplicateDetector
+	if config.Duplicates == DUPLICATES_GROUP || config.Duplicates == DUPLICATES_DROP {
+		dedupe = newDuplicateDetector(config.DuplicateThreshold)
+	}
+
+	if err := config.Converter.Validate(); err != nil {
+		fmt.Fprintf(config.Log, "⚠️  Invalid converter options, using the defaults: %v\n", err)
+		config.Converter = ConverterOptions{}
+		config.Converter.Validate()
+	}
+	converter := newConverter(config.Converter)
+
+	if config.Ordering == nil && config.Order != "" {
+		ordering, err := ParseOrdering(config.Order, config.Prefer)
+		if err != nil {
+			fmt.Fprintf(config.Log, "⚠️  Invalid crawl order, using bfs: %v\n", err)
+		}
+		config.Ordering = ordering
+	}
+
+	var guard *NetworkGuard
+	if config.BlockPrivateNetworks {
+		guard = NewNetworkGuard(config.AllowedHosts)
+	}
+
+	fetcher := config.Fetcher
+	if fetcher == nil {
+		httpFetcher := NewHTTPFetcher(config.UserAgent)
+		if guard != nil {
+			httpFetcher.Client.Transport = guard.Transport()
+		}
+		if config.WARC != nil {
+			httpFetcher.RecordTo(config.WARC)
+		}
+		fetcher = httpFetcher
+	}
 
 	return &Scraper{
 		config:         *config,
 		visited:        make(map[string]bool),
+		seedHosts:      make(map[string]string),
 		converter:      converter,
 		duplicateCount: 0,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		fetcher:        fetcher,
+		guard:          guard,
+		polite:         newPoliteness(config.Concurrency, config.Delay),
+		frontier:       NewFrontier(config.Ordering),
+		dedupe:         dedupe,
 	}
 }
 
 func (s *Scraper) ScrapeWebsite(startURL string) ([]*ScrapedPage, error) {
-	parsedURL, err := url.Parse(startURL)
-	if err != nil {
-		return nil, fmt.Errorf("🚫 Invalid URL: %v", err)
+	return s.ScrapeWebsiteContext(context.Background(), startURL)
+}
+
+// ScrapeWebsiteContext is like ScrapeWebsite but stops when ctx is cancelled.
+// The pages scraped so far are returned together with ctx.Err().
+func (s *Scraper) ScrapeWebsiteContext(ctx context.Context, startURL string) ([]*ScrapedPage, error) {
+	return s.ScrapeWebsitesContext(ctx, []string{startURL})
+}
+
+// ScrapeWebsites crawls several seed URLs in one run. The seeds share the
+// visited set, page budget and politeness limits, but each seed keeps its
+// own scope: links are followed only within the host of the seed they were
+// found from (unless FollowExternal is set).
+func (s *Scraper) ScrapeWebsites(startURLs []string) ([]*ScrapedPage, error) {
+	return s.ScrapeWebsitesContext(context.Background(), startURLs)
+}
+
+// ScrapeWebsitesContext is like ScrapeWebsites but stops when ctx is cancelled.
+func (s *Scraper) ScrapeWebsitesContext(ctx context.Context, startURLs []string) ([]*ScrapedPage, error) {
+	var results []*ScrapedPage
+	err := s.ScrapeTo(ctx, PageSinkFunc(func(page *ScrapedPage) error {
+		results = append(results, page)
+		return nil
+	}), startURLs...)
+	return results, err
+}
+
+// ScrapeTo crawls startURLs and hands every page to sink as soon as it is
+// scraped, without keeping pages in memory. Pages are delivered in the order
+// they finish. If sink returns an error the crawl stops and that error is
+// returned.
+func (s *Scraper) ScrapeTo(ctx context.Context, sink PageSink, startURLs ...string) error {
+	if len(startURLs) == 0 {
+		return fmt.Errorf("🚫 No URLs to scrape")
 	}
 
-	s.baseHost = parsedURL.Host
+	var seeds []crawlTarget
+	for _, startURL := range startURLs {
+		parsedURL, err := url.Parse(startURL)
+		if err != nil {
+			return fmt.Errorf("🚫 Invalid URL %s: %v", startURL, err)
+		}
 
-	// Normalize the starting URL
-	normalizedStartURL := s.normalizeURL(startURL)
-	fmt.Printf("🚀 Starting level-based scrape of %s (max depth: %d, concurrency: %d)\n", normalizedStartURL, s.config.MaxDepth, s.config.Concurrency)
+		if s.guard != nil {
+			if err := s.guard.CheckURL(ctx, startURL); err != nil {
+				return err
+			}
+		}
 
-	results := s.scrapeLevelBFS(normalizedStartURL)
+		// Normalize the starting URL
+		normalizedStartURL := s.normalizeURL(startURL)
+		s.addSeed(normalizedStartURL, parsedURL.Host)
+		seeds = append(seeds, crawlTarget{URL: normalizedStartURL, Seed: normalizedStartURL})
+	}
 
-	if s.duplicateCount > 0 {
-		fmt.Printf("✅ Scraping completed! Found %d unique pages (skipped %d duplicates)\n", len(results), s.duplicateCount)
+	if len(seeds) == 1 {
+		s.logf("🚀 Starting scrape of %s (max depth: %d, concurrency: %d)\n", seeds[0].URL, s.config.MaxDepth, s.config.Concurrency)
 	} else {
-		fmt.Printf("✅ Scraping completed! Found %d pages\n", len(results))
+		s.logf("🚀 Starting scrape of %d seeds (max depth: %d, concurrency: %d)\n", len(seeds), s.config.MaxDepth, s.config.Concurrency)
 	}
-	return results, nil
+
+	return s.crawl(ctx, seeds, sink)
 }
 
-func (s *Scraper) scrapeLevelBFS(startURL string) []*ScrapedPage {
-	var results []*ScrapedPage
+// crawl scrapes targets and everything reachable from them, then reports
+// how it went.
+func (s *Scraper) crawl(ctx context.Context, targets []crawlTarget, sink PageSink) error {
+	s.prepareOrdering(ctx)
+	for _, target := range s.filterUnvisited(targets) {
+		s.frontier.Push(FrontierEntry{URL: target.URL, Seed: target.Seed, Depth: 0})
+	}
+	return s.run(ctx, sink)
+}
 
-	// Start with initial URL
-	currentLevel := []string{startURL}
-	s.visited[startURL] = true
+// run scrapes everything in the frontier and reports how it went.
+func (s *Scraper) run(ctx context.Context, sink PageSink) error {
+	err := s.schedule(ctx, sink)
+	if err != nil {
+		s.logf("🛑 Scraping stopped after %d pages: %v\n", s.pageCount, err)
+		return err
+	}
 
-	// Process each level (depth)
-	for depth := 0; depth <= s.config.MaxDepth && len(currentLevel) > 0; depth++ {
-		fmt.Printf("📍 Processing depth %d (%d pages)...\n", depth, len(currentLevel))
+	if err := ctx.Err(); err != nil {
+		s.logf("🛑 Scraping cancelled after %d pages: %v\n", s.pageCount, err)
+		return err
+	}
 
-		// Phase 1: Scrape full content for all pages at current level
-		pagesAtThisLevel := s.scrapePagesConcurrent(currentLevel, depth)
-		results = append(results, pagesAtThisLevel...)
+	if s.duplicateCount > 0 {
+		s.logf("✅ Scraping completed! Found %d unique pages (skipped %d duplicates)\n", s.pageCount, s.duplicateCount)
+	} else {
+		s.logf("✅ Scraping completed! Found %d pages\n", s.pageCount)
+	}
+	if s.droppedCount > 0 {
+		s.logf("🧬 Left out %d near-duplicate pages\n", s.droppedCount)
+	}
+	return nil
+}
 
-		// Phase 2: Discover all links from pages at this level
-		nextLevelLinks := s.discoverLinksConcurrent(currentLevel)
+// prepareOrdering loads what the ordering needs before URLs are queued,
+// since entries are scored when they are pushed.
+func (s *Scraper) prepareOrdering(ctx context.Context) {
+	if order, ok := s.config.Ordering.(*SitemapOrder); ok {
+		s.loadSitemaps(ctx, order)
+	}
+}
 
-		// Filter: only keep unvisited links for next level
-		currentLevel = s.filterUnvisited(nextLevelLinks)
+// crawlTarget is a URL waiting to be scraped, together with the seed whose
+// scope it belongs to.
+type crawlTarget struct {
+	URL  string
+	Seed string
+}
 
-		if len(currentLevel) > 0 {
-			fmt.Printf("🔗 Found %d new pages for depth %d\n", len(currentLevel), depth+1)
-		}
+// schedule keeps up to Concurrency pages in flight, taking the next one from
+// the frontier as soon as a slot frees up and its host's delay has passed,
+// so one slow page never holds up the rest of the crawl. Pages are passed to
+// sink as they finish. It returns the sink's error, if any.
+func (s *Scraper) schedule(ctx context.Context, sink PageSink) error {
+	type result struct {
+		entry FrontierEntry
+		page  *ScrapedPage
+		links []string
 	}
 
-	return results
-}
+	// Stop the remaining fetches if the sink fails
+	parentCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result)
+	inFlight := make(map[string]FrontierEntry)
+	var sinkErr error
+	limitReached := false
+
+	handle := func(r result) {
+		// Pages that didn't make it go back to the frontier for a later
+		// resume
+		if sinkErr != nil || (r.page == nil && ctx.Err() != nil) {
+			s.frontier.Push(r.entry)
+			return
+		}
 
-func (s *Scraper) scrapePagesConcurrent(urls []string, depth int) []*ScrapedPage {
-	var wg sync.WaitGroup
-	pagesChan := make(chan *ScrapedPage, len(urls))
+		// Revisited pages are in the output already, only their links are new
+		page := r.page
+		if r.entry.Revisit {
+			page = nil
+		}
 
-	for _, u := range urls {
-		wg.Add(1)
-		go func(url string) {
-			defer wg.Done()
+		dropped := false
+		if page != nil && page.Error == "" && s.dedupe != nil && s.dedupe.check(page) {
+			s.logf("🧬 Near-duplicate of %s: %s\n", page.DuplicateOf, page.URL)
+			dropped = s.config.Duplicates == DUPLICATES_DROP
+		}
 
-			fmt.Printf("📄 Scraping (depth %d): %s\n", depth, url)
-			page, _ := s.scrapePage(url, depth)
-			if page != nil {
-				pagesChan <- page
+		if dropped {
+			s.droppedCount++
+		} else if page != nil {
+			if sinkErr = sink.WritePage(page); sinkErr != nil {
+				s.frontier.Push(r.entry)
+				cancel()
+				return
 			}
-		}(u)
+			s.pageCount++
+			s.tokenCount += page.Tokens
+		}
+
+		var links []crawlTarget
+		for _, link := range r.links {
+			links = append(links, crawlTarget{URL: s.normalizeURL(link), Seed: r.entry.Seed})
+		}
+		for _, link := range s.filterUnvisited(links) {
+			s.frontier.Push(FrontierEntry{URL: link.URL, Seed: link.Seed, Depth: r.entry.Depth + 1})
+		}
+
+		s.completed = append(s.completed, r.entry.URL)
+		s.saveCheckpoint(inFlight, false)
 	}
 
-	go func() {
-		wg.Wait()
-		close(pagesChan)
-	}()
+	for {
+		// Fill the free slots with the best entries whose host is ready
+		for sinkErr == nil && ctx.Err() == nil && !limitReached && len(inFlight) < s.config.Concurrency {
+			if s.config.MaxPages > 0 && s.pageCount+len(inFlight) >= s.config.MaxPages {
+				break
+			}
+			if s.tokenBudgetSpent() {
+				break
+			}
 
-	var pages []*ScrapedPage
-	for page := range pagesChan {
-		pages = append(pages, page)
+			entry, ok := s.frontier.Pop(s.polite.tryReserve)
+			if !ok {
+				break
+			}
+
+			inFlight[entry.URL] = entry
+			go func(entry FrontierEntry) {
+				if entry.Revisit {
+					s.logf("🔗 Fetching links again (depth %d): %s\n", entry.Depth, entry.URL)
+				} else {
+					s.logf("📄 Scraping (depth %d): %s\n", entry.Depth, entry.URL)
+				}
+				page, links := s.scrapePage(ctx, crawlTarget{URL: entry.URL, Seed: entry.Seed}, entry.Depth)
+				results <- result{entry: entry, page: page, links: links}
+			}(entry)
+		}
+
+		if s.config.MaxPages > 0 && s.pageCount >= s.config.MaxPages && s.frontier.Len() > 0 && !limitReached {
+			s.logf("🛑 Page limit of %d reached, stopping\n", s.config.MaxPages)
+			limitReached = true
+		}
+		if s.tokenBudgetSpent() && s.frontier.Len() > 0 && !limitReached {
+			s.logf("🛑 Token budget of %d reached (%d tokens), stopping\n", s.config.MaxTokens, s.tokenCount)
+			limitReached = true
+		}
+
+		stopped := sinkErr != nil || ctx.Err() != nil || limitReached
+		if len(inFlight) == 0 && (stopped || s.frontier.Len() == 0) {
+			break
+		}
+
+		// Wait for a page to finish, or for a queued host's delay to pass
+		var wake <-chan time.Time
+		var timer *time.Timer
+		if !stopped && len(inFlight) < s.config.Concurrency && s.frontier.Len() > 0 {
+			timer = time.NewTimer(s.nextHostWait())
+			wake = timer.C
+		}
+
+		select {
+		case r := <-results:
+			delete(inFlight, r.entry.URL)
+			handle(r)
+		case <-wake:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+
+	// Leave a checkpoint to resume from when the crawl was interrupted
+	if sinkErr != nil || parentCtx.Err() != nil {
+		s.saveCheckpoint(nil, true)
 	}
 
-	return pages
+	return sinkErr
 }
 
-func (s *Scraper) discoverLinksConcurrent(urls []string) []string {
-	var wg sync.WaitGroup
-	linksChan := make(chan []string, len(urls))
-
-	for _, u := range urls {
-		wg.Add(1)
-		go func(url string) {
-			defer wg.Done()
-			_, links := s.scrapePage(url, 0)
-			linksChan <- links
-		}(u)
-	}
-
-	go func() {
-		wg.Wait()
-		close(linksChan)
-	}()
-
-	var allLinks []string
-	seen := make(map[string]bool)
-
-	for links := range linksChan {
-		for _, link := range links {
-			normalized := s.normalizeURL(link)
-			if !seen[normalized] {
-				seen[normalized] = true
-				allLinks = append(allLinks, normalized)
-			}
+func (s *Scraper) tokenBudgetSpent() bool {
+	return s.config.MaxTokens > 0 && s.tokenCount >= s.config.MaxTokens
+}
+
+// nextHostWait returns how long until one of the queued hosts may be
+// requested again.
+func (s *Scraper) nextHostWait() time.Duration {
+	var next time.Duration
+	for i, host := range s.frontier.Hosts() {
+		if wait := s.polite.wait(host); i == 0 || wait < next {
+			next = wait
 		}
 	}
+	if next < time.Millisecond {
+		next = time.Millisecond
+	}
+	return next
+}
+
+func (s *Scraper) addSeed(seed, host string) {
+	if _, exists := s.seedHosts[seed]; !exists {
+		s.seeds = append(s.seeds, seed)
+	}
+	s.seedHosts[seed] = host
+}
 
-	return allLinks
+func (s *Scraper) logf(format string, args ...interface{}) {
+	fmt.Fprintf(s.config.Log, format, args...)
 }
 
-func (s *Scraper) filterUnvisited(urls []string) []string {
+func (s *Scraper) filterUnvisited(targets []crawlTarget) []crawlTarget {
 	s.visitedMutex.Lock()
 	defer s.visitedMutex.Unlock()
 
-	var unvisited []string
-	for _, url := range urls {
-		if !s.visited[url] {
-			s.visited[url] = true
-			unvisited = append(unvisited, url)
+	var unvisited []crawlTarget
+	for _, target := range targets {
+		if !s.visited[target.URL] {
+			s.visited[target.URL] = true
+			unvisited = append(unvisited, target)
 		} else {
 			s.duplicateCount++
 		}
@@ -206,77 +492,136 @@ func (s *Scraper) filterUnvisited(urls []string) []string {
 	return unvisited
 }
 
-func (s *Scraper) scrapePage(pageURL string, depth int) (*ScrapedPage, []string) {
+// SeedGroup holds the pages that were reached from one seed URL.
+type SeedGroup struct {
+	Seed  string         `json:"seed"`
+	Pages []*ScrapedPage `json:"pages"`
+}
+
+// GroupBySeed groups pages by their seed, in the order seeds first appear
+// (which is the order they were given in).
+func GroupBySeed(pages []*ScrapedPage) []SeedGroup {
+	var groups []SeedGroup
+	index := make(map[string]int)
+	for _, page := range pages {
+		i, exists := index[page.Seed]
+		if !exists {
+			i = len(groups)
+			index[page.Seed] = i
+			groups = append(groups, SeedGroup{Seed: page.Seed})
+		}
+		groups[i].Pages = append(groups[i].Pages, page)
+	}
+	return groups
+}
+
+func (s *Scraper) scrapePage(ctx context.Context, target crawlTarget, depth int) (*ScrapedPage, []string) {
+	pageURL := target.URL
 	page := &ScrapedPage{
 		URL:   pageURL,
+		Seed:  target.Seed,
 		Depth: depth,
 	}
 
-	req, err := http.NewRequest("GET", pageURL, nil)
+	doc, err := s.fetchDocument(ctx, pageURL)
 	if err != nil {
-		page.Error = fmt.Sprintf("Failed to create request: %v", err)
+		if ctx.Err() != nil {
+			return nil, nil // Cancelled, not a page error
+		}
+		page.Error = err.Error()
 		return page, nil
 	}
+	fetchedAt := time.Now().UTC()
+	page.FetchedAt = &fetchedAt
 
-	req.Header.Set("User-Agent", s.config.UserAgent)
+	if err := s.convertDocument(doc, page); err != nil {
+		page.Error = err.Error()
+		return page, nil
+	}
 
-	resp, err := s.client.Do(req)
+	// Filter out pages with minimal or generic content
+	if s.isContentMinimal(page.Title, page.Markdown, pageURL) {
+		s.logf("⏭️  Skipping page with minimal content: %s\n", pageURL)
+		return nil, nil // Return nil to skip this page
+	}
+	for _, warning := range page.Warnings {
+		s.logf("⚠️  %s: %s\n", pageURL, warning)
+	}
+
+	// Extract links for recursive scraping, relative to where we ended up
+	var links []string
+	if depth < s.config.MaxDepth {
+		baseURL := pageURL
+		if doc.Url != nil {
+			baseURL = doc.Url.String()
+		}
+		links = s.extractLinks(doc, baseURL, s.seedHosts[target.Seed])
+	}
+
+	return page, links
+}
+
+// fetchDocument downloads pageURL and parses it as HTML. The document's Url
+// is the final URL after redirects. Callers take care of politeness.
+func (s *Scraper) fetchDocument(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	resp, err := s.fetcher.Fetch(ctx, pageURL)
 	if err != nil {
-		page.Error = fmt.Sprintf("Failed to fetch page: %v", err)
-		return page, nil
+		return nil, fmt.Errorf("Failed to fetch page: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		page.Error = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status)
-		return page, nil
+		return nil, fmt.Errorf("HTTP %d: %d %s", resp.StatusCode, resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
 	// Check if content is HTML
 	contentType := resp.Header.Get("Content-Type")
 	if !strings.Contains(contentType, "text/html") {
-		page.Error = "Not an HTML page"
-		return page, nil
+		return nil, fmt.Errorf("Not an HTML page")
 	}
 
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		page.Error = fmt.Sprintf("Failed to parse HTML: %v", err)
-		return page, nil
+		return nil, fmt.Errorf("Failed to parse HTML: %v", err)
 	}
 
+	doc.Url, _ = url.Parse(resp.URL)
+	return doc, nil
+}
+
+// convertDocument extracts the title and metadata and converts doc to
+// cleaned markdown. What didn't convert faithfully goes to page.Warnings;
+// callers decide whether to log it.
+func (s *Scraper) convertDocument(doc *goquery.Document, page *ScrapedPage) error {
 	// Extract title
 	page.Title = doc.Find("title").First().Text()
 	if page.Title == "" {
-		page.Title = pageURL
+		page.Title = page.URL
+	}
+
+	baseURL := page.URL
+	if doc.Url != nil {
+		baseURL = doc.Url.String()
 	}
+	page.Metadata = extractMetadata(doc, baseURL)
 
 	// Convert to markdown
+	normalizeCodeBlocks(doc)
+	page.Warnings = normalizeTables(doc, s.config.Converter)
 	html, _ := doc.Html()
 	markdown, err := s.converter.ConvertString(html)
 	if err != nil {
-		page.Error = fmt.Sprintf("Failed to convert to markdown: %v", err)
-		return page, nil
+		return fmt.Errorf("Failed to convert to markdown: %v", err)
 	}
 
 	page.Markdown = s.cleanMarkdown(markdown)
-
-	// Filter out pages with minimal or generic content
-	if s.isContentMinimal(page.Title, page.Markdown, pageURL) {
-		fmt.Printf("⏭️  Skipping page with minimal content: %s\n", pageURL)
-		return nil, nil // Return nil to skip this page
-	}
-
-	// Extract links for recursive scraping
-	var links []string
-	if depth < s.config.MaxDepth {
-		links = s.extractLinks(doc, pageURL)
-	}
-
-	return page, links
+	digestPage(page, s.config.Tokenizer)
+	return nil
 }
 
-func (s *Scraper) extractLinks(doc *goquery.Document, baseURL string) []string {
+// extractLinks returns the crawlable links of doc. Unless FollowExternal is
+// set, only links on scopeHost (the host of the page's seed) are kept.
+func (s *Scraper) extractLinks(doc *goquery.Document, baseURL, scopeHost string) []string {
 	var links []string
 	seenLinks := make(map[string]bool)
 
@@ -296,13 +641,15 @@ func (s *Scraper) extractLinks(doc *goquery.Document, baseURL string) []string {
 		resolvedURL := parsedBase.ResolveReference(parsedHref)
 		finalURL := resolvedURL.String()
 
-		// Skip non-HTTP(S) URLs
-		if !strings.HasPrefix(finalURL, "http://") && !strings.HasPrefix(finalURL, "https://") {
+		// Skip non-HTTP(S) URLs, unless they share the page's own scheme
+		// (e.g. file:// pages linking to each other)
+		if !strings.HasPrefix(finalURL, "http://") && !strings.HasPrefix(finalURL, "https://") &&
+			resolvedURL.Scheme != parsedBase.Scheme {
 			return
 		}
 
 		// Skip if external and not following external links
-		if !s.config.FollowExternal && resolvedURL.Host != s.baseHost {
+		if !s.config.FollowExternal && resolvedURL.Host != scopeHost {
 			return
 		}
 
@@ -0,0 +1,182 @@
+package scraper
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DEFAULT_SITEMAP_PRIORITY = 0.5      // sitemaps.org default for a listed URL
+	MAX_SITEMAP_FILES        = 20       // per host, including nested sitemap indexes
+	MAX_SITEMAP_URLS         = 50000    // priorities loaded per host
+	MAX_SITEMAP_BYTES        = 50 << 20 // per file, the sitemaps.org limit
+)
+
+// Sitemap is a parsed sitemap.xml: page URLs, or for a sitemap index, the
+// URLs of more sitemaps.
+type Sitemap struct {
+	URLs     []SitemapURL
+	Sitemaps []string
+}
+
+type SitemapURL struct {
+	Loc      string
+	Priority float64
+}
+
+// ParseSitemap reads a sitemap or sitemap index. URLs without a priority
+// get DEFAULT_SITEMAP_PRIORITY.
+func ParseSitemap(r io.Reader) (*Sitemap, error) {
+	var document struct {
+		URLs []struct {
+			Loc      string `xml:"loc"`
+			Priority string `xml:"priority"`
+		} `xml:"url"`
+		Sitemaps []struct {
+			Loc string `xml:"loc"`
+		} `xml:"sitemap"`
+	}
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid sitemap: %v", err)
+	}
+
+	sitemap := &Sitemap{}
+	for _, entry := range document.URLs {
+		priority, err := strconv.ParseFloat(strings.TrimSpace(entry.Priority), 64)
+		if err != nil {
+			priority = DEFAULT_SITEMAP_PRIORITY
+		}
+		sitemap.URLs = append(sitemap.URLs, SitemapURL{Loc: strings.TrimSpace(entry.Loc), Priority: priority})
+	}
+	for _, entry := range document.Sitemaps {
+		sitemap.Sitemaps = append(sitemap.Sitemaps, strings.TrimSpace(entry.Loc))
+	}
+	return sitemap, nil
+}
+
+// SitemapOrder scores URLs by their <priority> in the sitemaps of the crawled
+// hosts. The scraper loads /sitemap.xml of every seed host before crawling;
+// URLs missing from the sitemaps score 0 and come last.
+type SitemapOrder struct {
+	mutex      sync.RWMutex
+	priorities map[string]float64
+	loaded     map[string]bool // hosts whose sitemaps were loaded
+}
+
+func NewSitemapOrder() *SitemapOrder {
+	return &SitemapOrder{
+		priorities: make(map[string]float64),
+		loaded:     make(map[string]bool),
+	}
+}
+
+func (o *SitemapOrder) Score(entry FrontierEntry) float64 {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.priorities[entry.URL]
+}
+
+// Set records the priority of pageURL, which must be normalized the way the
+// crawl normalizes URLs.
+func (o *SitemapOrder) Set(pageURL string, priority float64) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.priorities[pageURL] = priority
+}
+
+// loadSitemaps reads the sitemaps of every seed host into order, following
+// sitemap indexes. A host without a sitemap is crawled in plain order.
+func (s *Scraper) loadSitemaps(ctx context.Context, order *SitemapOrder) {
+	for _, seed := range s.seeds {
+		seedURL, err := url.Parse(seed)
+		if err != nil {
+			continue
+		}
+
+		order.mutex.Lock()
+		loaded := order.loaded[seedURL.Host]
+		order.loaded[seedURL.Host] = true
+		order.mutex.Unlock()
+		if loaded {
+			continue
+		}
+
+		sitemapURL := seedURL.Scheme + "://" + seedURL.Host + "/sitemap.xml"
+		count, err := s.loadSitemap(ctx, order, sitemapURL)
+		if err != nil {
+			s.logf("⚠️  No sitemap for %s: %v\n", seedURL.Host, err)
+			continue
+		}
+		s.logf("🗺️  Loaded %d URL priorities from %s\n", count, sitemapURL)
+	}
+}
+
+// loadSitemap reads sitemapURL and the sitemaps it lists into order, up to
+// MAX_SITEMAP_FILES files and MAX_SITEMAP_URLS URLs. It returns the number
+// of URLs loaded.
+func (s *Scraper) loadSitemap(ctx context.Context, order *SitemapOrder, sitemapURL string) (int, error) {
+	queue := []string{sitemapURL}
+	count := 0
+	for files := 0; len(queue) > 0 && count < MAX_SITEMAP_URLS; files++ {
+		current := queue[0]
+		queue = queue[1:]
+
+		sitemap, err := s.fetchSitemap(ctx, current)
+		if err != nil {
+			if current == sitemapURL {
+				return 0, err
+			}
+			s.logf("⚠️  Skipping sitemap %s: %v\n", current, err)
+			continue
+		}
+
+		for _, entry := range sitemap.URLs {
+			if count == MAX_SITEMAP_URLS {
+				s.logf("⚠️  Sitemap limit of %d URLs reached at %s\n", MAX_SITEMAP_URLS, current)
+				break
+			}
+			order.Set(s.normalizeURL(entry.Loc), entry.Priority)
+			count++
+		}
+
+		// Only queue the sitemaps that will be fetched
+		children := sitemap.Sitemaps
+		if room := MAX_SITEMAP_FILES - files - 1 - len(queue); len(children) > room {
+			s.logf("⚠️  Sitemap limit of %d files reached, skipping %d sitemaps listed in %s\n", MAX_SITEMAP_FILES, len(children)-max(room, 0), current)
+			children = children[:max(room, 0)]
+		}
+		queue = append(queue, children...)
+	}
+	return count, nil
+}
+
+// fetchSitemap fetches a sitemap file, waiting for its host's delay like a
+// page fetch, so a large sitemap index isn't fetched in one burst.
+func (s *Scraper) fetchSitemap(ctx context.Context, sitemapURL string) (*Sitemap, error) {
+	parsedURL, err := url.Parse(sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.polite.acquire(ctx, parsedURL.Host); err != nil {
+		return nil, err
+	}
+	resp, err := s.fetcher.Fetch(ctx, sitemapURL)
+	s.polite.release()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	// A bigger file is cut off, and fails to parse
+	return ParseSitemap(io.LimitReader(resp.Body, MAX_SITEMAP_BYTES))
+}
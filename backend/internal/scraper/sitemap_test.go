@@ -0,0 +1,117 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// timedFetcher records when each fetch starts.
+type timedFetcher struct {
+	Fetcher
+	mutex  sync.Mutex
+	starts []time.Time
+}
+
+func (f *timedFetcher) Fetch(ctx context.Context, rawURL string) (*FetchResponse, error) {
+	f.mutex.Lock()
+	f.starts = append(f.starts, time.Now())
+	f.mutex.Unlock()
+	return f.Fetcher.Fetch(ctx, rawURL)
+}
+
+func TestLoadSitemapWaitsForTheHostDelay(t *testing.T) {
+	const delay = 50 * time.Millisecond
+
+	site := MemoryFetcher{}
+	index := "<sitemapindex>"
+	for i := 0; i < 3; i++ {
+		sitemapURL := fmt.Sprintf("https://example.com/sitemap-%d.xml", i)
+		index += "<sitemap><loc>" + sitemapURL + "</loc></sitemap>"
+		site[sitemapURL] = "<urlset><url><loc>" + sitemapURL + ".html</loc><priority>0.8</priority></url></urlset>"
+	}
+	site["https://example.com/sitemap.xml"] = index + "</sitemapindex>"
+	fetcher := &timedFetcher{Fetcher: site}
+
+	// The delay is kept between the starts of the fetches, so time them
+	// where they start rather than where they arrive
+	s := NewScraper(&ScrapingConfig{Delay: delay, Fetcher: fetcher, Log: io.Discard})
+	order := NewSitemapOrder()
+	count, err := s.loadSitemap(context.Background(), order, "https://example.com/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("loaded %d URLs, want 3", count)
+	}
+	if score := order.Score(FrontierEntry{URL: s.normalizeURL("https://example.com/sitemap-0.xml.html")}); score != 0.8 {
+		t.Errorf("Score() = %v, want 0.8", score)
+	}
+
+	if len(fetcher.starts) != 4 {
+		t.Fatalf("got %d fetches, want 4", len(fetcher.starts))
+	}
+	for i := 1; i < len(fetcher.starts); i++ {
+		// A fetch that starts a little after its slot shortens the next gap
+		if gap := fetcher.starts[i].Sub(fetcher.starts[i-1]); gap < delay-5*time.Millisecond {
+			t.Errorf("fetch %d started %v after the previous one, want at least %v", i, gap, delay)
+		}
+	}
+}
+
+func TestLoadSitemapLimits(t *testing.T) {
+	var mutex sync.Mutex
+	requests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/sitemap.xml", "/with-big.xml":
+			io.WriteString(w, "<sitemapindex>")
+			if r.URL.Path == "/with-big.xml" {
+				fmt.Fprintf(w, "<sitemap><loc>%s/big.xml</loc></sitemap>", server.URL)
+			}
+			for i := 0; i < 2*MAX_SITEMAP_FILES; i++ {
+				fmt.Fprintf(w, "<sitemap><loc>%s/sitemap-%d.xml</loc></sitemap>", server.URL, i)
+			}
+			io.WriteString(w, "</sitemapindex>")
+		case "/big.xml":
+			io.WriteString(w, "<urlset>")
+			for i := 0; i <= MAX_SITEMAP_URLS; i++ {
+				fmt.Fprintf(w, "<url><loc>%s/page-%d</loc></url>", server.URL, i)
+			}
+			io.WriteString(w, "</urlset>")
+		default:
+			fmt.Fprintf(w, "<urlset><url><loc>%s%s.html</loc></url></urlset>", server.URL, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	s := NewScraper(&ScrapingConfig{Log: io.Discard})
+
+	tests := []struct {
+		sitemap  string
+		requests int
+		urls     int
+	}{
+		{"/sitemap.xml", MAX_SITEMAP_FILES, MAX_SITEMAP_FILES - 1},
+		{"/with-big.xml", 2, MAX_SITEMAP_URLS},
+	}
+	for _, test := range tests {
+		requests = 0
+		count, err := s.loadSitemap(context.Background(), NewSitemapOrder(), server.URL+test.sitemap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if requests != test.requests || count != test.urls {
+			t.Errorf("%s: got %d requests and %d URLs, want %d and %d", test.sitemap, requests, count, test.requests, test.urls)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Ranges that are never reachable from a server-side crawl unless allowlisted.
+// Loopback, RFC1918, link-local (incl. 169.254.169.254) and multicast are
+// covered by the net.IP helpers in isBlockedIP.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // TEST-NET-1
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"240.0.0.0/4",     // reserved
+	"64:ff9b::/96",    // NAT64
+	"2001:db8::/32",   // documentation
+)
+
+// BlockedAddressError is returned when a host resolves to a blocked address.
+type BlockedAddressError struct {
+	Host string
+	IP   net.IP
+}
+
+func (e *BlockedAddressError) Error() string {
+	return fmt.Sprintf("🚫 Blocked request to %s (%s): private or internal address", e.Host, e.IP)
+}
+
+// NetworkGuard blocks outgoing connections to private, loopback, link-local
+// and cloud metadata addresses. Hosts and networks in the allowlist bypass
+// the check.
+type NetworkGuard struct {
+	allowedHosts    map[string]bool
+	allowedSuffixes []string
+	allowedNetworks []*net.IPNet
+	resolver        *net.Resolver
+	dialer          *net.Dialer
+}
+
+// NewNetworkGuard creates a guard with the given allowlist. Entries can be
+// hostnames ("intranet.local"), domain suffixes (".corp.example"), IPs or
+// CIDR ranges ("10.1.0.0/16").
+func NewNetworkGuard(allowlist []string) *NetworkGuard {
+	guard := &NetworkGuard{
+		allowedHosts: make(map[string]bool),
+		resolver:     net.DefaultResolver,
+		dialer: &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+	}
+
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			guard.allowedNetworks = append(guard.allowedNetworks, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			guard.allowedNetworks = append(guard.allowedNetworks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if strings.HasPrefix(entry, ".") {
+			guard.allowedSuffixes = append(guard.allowedSuffixes, entry)
+			continue
+		}
+		guard.allowedHosts[entry] = true
+	}
+
+	return guard
+}
+
+// CheckURL resolves the host of rawURL and returns an error if any of its
+// addresses is blocked.
+func (g *NetworkGuard) CheckURL(ctx context.Context, rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	host := parsedURL.Hostname()
+	if g.isHostAllowed(host) {
+		return nil
+	}
+
+	_, err = g.resolve(ctx, host)
+	return err
+}
+
+// DialContext is a drop-in replacement for net.Dialer.DialContext. It
+// resolves the hostname itself, rejects blocked addresses and then dials the
+// vetted IP directly, so a DNS rebinding between check and connect has no
+// effect. Redirects are covered because every new connection goes through it.
+func (g *NetworkGuard) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.isHostAllowed(host) {
+		return g.dialer.DialContext(ctx, network, address)
+	}
+
+	ips, err := g.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := g.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Transport returns an http.Transport that dials through the guard. It
+// ignores proxy settings: the guard must see the real destination of every
+// connection.
+func (g *NetworkGuard) Transport() *http.Transport {
+	return &http.Transport{
+		DialContext:           g.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func (g *NetworkGuard) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := g.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("🚫 No addresses found for %s", host)
+	}
+
+	// Reject the host if any of its addresses is blocked, so a round-robin
+	// record can't smuggle in an internal address
+	for _, ip := range ips {
+		if g.isBlockedIP(ip) {
+			return nil, &BlockedAddressError{Host: host, IP: ip}
+		}
+	}
+
+	return ips, nil
+}
+
+func (g *NetworkGuard) isHostAllowed(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if g.allowedHosts[host] {
+		return true
+	}
+	for _, suffix := range g.allowedSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *NetworkGuard) isBlockedIP(ip net.IP) bool {
+	for _, network := range g.allowedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNetworkGuardCheckURL(t *testing.T) {
+	guard := NewNetworkGuard([]string{"10.1.0.0/16", "127.0.0.1", "fd00::5"})
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"http://127.0.0.2/", true},
+		{"http://[::1]:8080/", true},
+		{"http://10.0.0.1/", true},
+		{"http://172.16.5.4/", true},
+		{"http://192.168.1.1/", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://[fe80::1]/", true},
+		{"http://[fd00::1]/", true},
+		{"http://0.0.0.0/", true},
+		{"http://100.64.0.1/", true},
+		{"http://203.0.113.7/", true},
+		{"http://224.0.0.1/", true},
+		{"http://[64:ff9b::a00:1]/", true},
+		{"http://93.184.216.34/", false},
+		{"https://[2606:4700::1111]/", false},
+		// Allowlisted
+		{"http://10.1.2.3/", false},
+		{"http://127.0.0.1:3000/", false},
+		{"http://[fd00::5]/", false},
+		{"http://10.2.0.1/", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			err := guard.CheckURL(context.Background(), test.url)
+			var blockedErr *BlockedAddressError
+			if blocked := errors.As(err, &blockedErr); blocked != test.blocked {
+				t.Errorf("CheckURL() error = %v, want blocked %t", err, test.blocked)
+			}
+		})
+	}
+}
+
+func TestNetworkGuardAllowedHosts(t *testing.T) {
+	guard := NewNetworkGuard([]string{" Intranet.Local ", ".corp.example", ""})
+
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{"intranet.local", true},
+		{"INTRANET.local.", true},
+		{"wiki.corp.example", true},
+		{"a.b.corp.example", true},
+		{"corp.example", false},
+		{"evilcorp.example", false},
+		{"corp.example.evil.com", false},
+		{"other.local", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if allowed := guard.isHostAllowed(test.host); allowed != test.allowed {
+			t.Errorf("isHostAllowed(%q) = %t, want %t", test.host, allowed, test.allowed)
+		}
+	}
+}
+
+func TestNetworkGuardDial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// Allowlisted by name, but the redirect target is not
+			http.Redirect(w, r, "http://127.0.0.1:1/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		url       string
+		blocked   bool
+	}{
+		{"loopback IP", nil, "http://127.0.0.1:" + port + "/", true},
+		{"name of a loopback address", nil, "http://localhost:" + port + "/", true},
+		{"allowlisted IP", []string{"127.0.0.1"}, "http://127.0.0.1:" + port + "/", false},
+		{"allowlisted network", []string{"127.0.0.0/8"}, "http://127.0.0.1:" + port + "/", false},
+		{"allowlisted host", []string{"localhost"}, "http://localhost:" + port + "/", false},
+		{"redirect to a blocked address", []string{"localhost"}, "http://localhost:" + port + "/redirect", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: NewNetworkGuard(test.allowlist).Transport()}
+			resp, err := client.Get(test.url)
+			if err == nil {
+				resp.Body.Close()
+			}
+			var blockedErr *BlockedAddressError
+			if blocked := errors.As(err, &blockedErr); blocked != test.blocked {
+				t.Errorf("Get() error = %v, want blocked %t", err, test.blocked)
+			}
+			if !test.blocked && (err != nil || resp.StatusCode != http.StatusNoContent) {
+				t.Errorf("Get() = %v, %v, want the server's response", resp, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"context"
+)
+
+// PageSink receives pages as they are scraped. Returning an error stops the
+// crawl.
+type PageSink interface {
+	WritePage(page *ScrapedPage) error
+}
+
+// PageSinkFunc adapts a function to a PageSink.
+type PageSinkFunc func(page *ScrapedPage) error
+
+func (f PageSinkFunc) WritePage(page *ScrapedPage) error {
+	return f(page)
+}
+
+// Result is one item of ScrapeStream: either a page, or the error that
+// ended the crawl (always the last item).
+type Result struct {
+	Page *ScrapedPage
+	Err  error
+}
+
+// Stream is a crawl running in the background; see ScrapeStream.
+type Stream struct {
+	Results <-chan Result
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Close stops the crawl, if it is still running, and waits for it to end.
+// Call it when you stop reading Results before the channel is closed.
+func (st *Stream) Close() {
+	st.cancel()
+	<-st.done
+}
+
+// ScrapeStream crawls startURLs in the background and sends each page on
+// Results as soon as it is scraped. The channel is closed when the crawl
+// ends. Cancel ctx or Close the stream to stop early.
+func (s *Scraper) ScrapeStream(ctx context.Context, startURLs ...string) *Stream {
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan Result)
+	stream := &Stream{Results: results, cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(stream.done)
+		defer close(results)
+		defer cancel()
+
+		err := s.ScrapeTo(ctx, PageSinkFunc(func(page *ScrapedPage) error {
+			select {
+			case results <- Result{Page: page}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}), startURLs...)
+
+		if err != nil {
+			select {
+			case results <- Result{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return stream
+}
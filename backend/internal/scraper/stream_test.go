@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestScrapeStream(t *testing.T) {
+	links := map[string][]string{"/": nil}
+	for i := 0; i < 20; i++ {
+		path := fmt.Sprintf("/%d", i)
+		links["/"] = append(links["/"], path)
+		links[path] = nil
+	}
+	config := func() *ScrapingConfig {
+		return &ScrapingConfig{MaxDepth: 1, Concurrency: 1, Fetcher: linkedSite(links), Log: io.Discard}
+	}
+
+	stream := NewScraper(config()).ScrapeStream(context.Background(), "https://example.com/")
+	pages := 0
+	for result := range stream.Results {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		pages++
+	}
+	stream.Close()
+	if pages != len(links) {
+		t.Errorf("streamed %d pages, want %d", pages, len(links))
+	}
+
+	// A reader that gives up early doesn't leave the crawl blocked
+	stream = NewScraper(config()).ScrapeStream(context.Background(), "https://example.com/")
+	<-stream.Results
+	closed := make(chan struct{})
+	go func() {
+		stream.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() didn't stop the crawl")
+	}
+	if _, open := <-stream.Results; open {
+		t.Error("Results still open after Close()")
+	}
+}
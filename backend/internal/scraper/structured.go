@@ -0,0 +1,245 @@
+package scraper
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// StructuredData is the JSON-LD on a page that we understand: articles,
+// breadcrumbs and FAQs. Other schema.org types are ignored.
+type StructuredData struct {
+	Articles    []Article    `json:"articles,omitempty"`
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs,omitempty"`
+	FAQ         []FAQEntry   `json:"faq,omitempty"`
+}
+
+// Article is a schema.org Article, or one of its subtypes such as
+// BlogPosting or NewsArticle.
+type Article struct {
+	Type          string   `json:"type"`
+	Headline      string   `json:"headline,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Authors       []string `json:"authors,omitempty"`
+	Image         string   `json:"image,omitempty"`
+	DatePublished string   `json:"datePublished,omitempty"`
+	DateModified  string   `json:"dateModified,omitempty"`
+}
+
+// Breadcrumb is one step of a BreadcrumbList, in position order.
+type Breadcrumb struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// FAQEntry is one question of an FAQPage.
+type FAQEntry struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+var articleTypes = map[string]bool{
+	"Article": true, "BlogPosting": true, "NewsArticle": true, "TechArticle": true,
+	"ScholarlyArticle": true, "Report": true, "SocialMediaPosting": true,
+}
+
+// extractStructuredData parses every JSON-LD script of doc. Scripts that
+// aren't valid JSON are skipped.
+func extractStructuredData(doc *goquery.Document, baseURL string) *StructuredData {
+	data := &StructuredData{}
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(i int, sel *goquery.Selection) {
+		var value interface{}
+		if err := json.Unmarshal([]byte(sel.Text()), &value); err != nil {
+			return
+		}
+		for _, node := range jsonLDNodes(value) {
+			data.add(node, baseURL)
+		}
+	})
+
+	if len(data.Articles) == 0 && len(data.Breadcrumbs) == 0 && len(data.FAQ) == 0 {
+		return nil
+	}
+	return data
+}
+
+// jsonLDNodes flattens a JSON-LD document (an object, an array of objects or
+// an @graph) into its top-level nodes.
+func jsonLDNodes(value interface{}) []map[string]interface{} {
+	var nodes []map[string]interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			nodes = append(nodes, jsonLDNodes(item)...)
+		}
+	case map[string]interface{}:
+		if graph, exists := v["@graph"]; exists {
+			nodes = append(nodes, jsonLDNodes(graph)...)
+		} else {
+			nodes = append(nodes, v)
+		}
+	}
+	return nodes
+}
+
+func (d *StructuredData) add(node map[string]interface{}, baseURL string) {
+	for _, nodeType := range jsonLDStrings(node["@type"]) {
+		switch {
+		case articleTypes[nodeType]:
+			d.Articles = append(d.Articles, Article{
+				Type:          nodeType,
+				Headline:      jsonLDString(node["headline"]),
+				Description:   jsonLDString(node["description"]),
+				Authors:       jsonLDNames(node["author"]),
+				Image:         resolveOptionalURL(baseURL, jsonLDURL(node["image"])),
+				DatePublished: jsonLDString(node["datePublished"]),
+				DateModified:  jsonLDString(node["dateModified"]),
+			})
+		case nodeType == "BreadcrumbList":
+			d.Breadcrumbs = append(d.Breadcrumbs, parseBreadcrumbs(node, baseURL)...)
+		case nodeType == "FAQPage":
+			d.FAQ = append(d.FAQ, parseFAQ(node)...)
+		default:
+			continue
+		}
+		return
+	}
+}
+
+func parseBreadcrumbs(node map[string]interface{}, baseURL string) []Breadcrumb {
+	type positioned struct {
+		position float64
+		crumb    Breadcrumb
+	}
+
+	var items []positioned
+	for _, element := range jsonLDNodes(node["itemListElement"]) {
+		crumb := Breadcrumb{Name: jsonLDString(element["name"]), URL: jsonLDURL(element["item"])}
+		// The name may live on the nested item instead
+		if item, ok := element["item"].(map[string]interface{}); ok {
+			crumb.Name = firstNonEmpty(crumb.Name, jsonLDString(item["name"]))
+			crumb.URL = firstNonEmpty(jsonLDString(item["@id"]), crumb.URL)
+		}
+		if crumb.Name == "" {
+			continue
+		}
+		crumb.URL = resolveOptionalURL(baseURL, crumb.URL)
+
+		position, _ := element["position"].(float64)
+		items = append(items, positioned{position, crumb})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].position < items[j].position
+	})
+
+	crumbs := make([]Breadcrumb, len(items))
+	for i, item := range items {
+		crumbs[i] = item.crumb
+	}
+	return crumbs
+}
+
+func parseFAQ(node map[string]interface{}) []FAQEntry {
+	var entries []FAQEntry
+	for _, question := range jsonLDNodes(node["mainEntity"]) {
+		var answer string
+		for _, accepted := range jsonLDNodes(question["acceptedAnswer"]) {
+			if answer = jsonLDString(accepted["text"]); answer != "" {
+				break
+			}
+		}
+
+		name := jsonLDString(question["name"])
+		if name == "" || answer == "" {
+			continue
+		}
+		entries = append(entries, FAQEntry{Question: name, Answer: htmlToText(answer)})
+	}
+	return entries
+}
+
+// jsonLDString returns a JSON-LD value as text. Values can also be
+// {"@value": ...} objects.
+func jsonLDString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		return jsonLDString(v["@value"])
+	case []interface{}:
+		if len(v) > 0 {
+			return jsonLDString(v[0])
+		}
+	}
+	return ""
+}
+
+// jsonLDStrings returns a value that may be a string or a list of strings.
+func jsonLDStrings(value interface{}) []string {
+	if list, ok := value.([]interface{}); ok {
+		var values []string
+		for _, item := range list {
+			if s := jsonLDString(item); s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	if s := jsonLDString(value); s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+// jsonLDURL returns a URL given as a string, an object with url or @id, or
+// a list of those.
+func jsonLDURL(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		return firstNonEmpty(jsonLDString(v["url"]), jsonLDString(v["@id"]))
+	case []interface{}:
+		if len(v) > 0 {
+			return jsonLDURL(v[0])
+		}
+	}
+	return ""
+}
+
+// jsonLDNames returns the names of people or organizations, given as
+// strings, objects or a list of either.
+func jsonLDNames(value interface{}) []string {
+	var names []string
+	switch v := value.(type) {
+	case string:
+		if name := strings.TrimSpace(v); name != "" {
+			names = append(names, name)
+		}
+	case map[string]interface{}:
+		if name := jsonLDString(v["name"]); name != "" {
+			names = append(names, name)
+		}
+	case []interface{}:
+		for _, item := range v {
+			names = append(names, jsonLDNames(item)...)
+		}
+	}
+	return names
+}
+
+// htmlToText strips the markup that FAQ answers often contain.
+func htmlToText(html string) string {
+	if !strings.Contains(html, "<") {
+		return html
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return html
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
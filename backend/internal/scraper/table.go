@@ -0,0 +1,494 @@
+package scraper
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	TABLES_HTML    = "html"    // tables that don't fit a pipe table stay as sanitized HTML
+	TABLES_RECORDS = "records" // they become a list with one item per row (lossy)
+
+	// Marks a table that is written as HTML
+	tableHTMLAttr = "data-markdown-html"
+
+	// Cells and rows are delimited in the converted content of a table, so
+	// the table rule can lay them out
+	tableRowMark  = "\x1e"
+	tableCellMark = "\x1f"
+)
+
+// Tags kept in HTML tables, with the attributes they keep
+var tableHTMLTags = map[string][]string{
+	"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+	"th": {"colspan", "rowspan", "scope", "align"},
+	"td": {"colspan", "rowspan", "align"},
+	"a":  {"href", "title"}, "img": {"src", "alt", "title"},
+	"p": nil, "br": nil, "hr": nil, "blockquote": nil, "pre": nil, "code": nil, "kbd": nil, "samp": nil,
+	"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+	"strong": nil, "b": nil, "em": nil, "i": nil, "del": nil, "s": nil, "sub": nil, "sup": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+}
+
+const tableDroppedSelector = "script, style, noscript, template, iframe, object, embed, svg, canvas, button, input, select, textarea"
+
+// Attributes whose loss changes only how a table looks, not what it says
+var tableCosmeticAttrs = map[string]bool{
+	"class": true, "style": true, "id": true, "role": true, "width": true, "height": true, "valign": true,
+	"bgcolor": true, "border": true, "cellpadding": true, "cellspacing": true, "nowrap": true, tableHTMLAttr: true,
+}
+
+// normalizeTables prepares the tables of doc for conversion. Tables that a
+// GFM pipe table can represent are left to the table rules; the others are
+// sanitized and kept as HTML, or rewritten as a list of records, depending
+// on options.Tables. It returns a warning for every table that isn't a pipe
+// table, with what sanitizing dropped from it.
+func normalizeTables(doc *goquery.Document, options ConverterOptions) []string {
+	var warnings []string
+
+	// Innermost first, so a table is done before the table around it
+	tables := doc.Find("table")
+	for i := tables.Length() - 1; i >= 0; i-- {
+		table := tables.Eq(i)
+		reason := tableComplexity(table)
+		if reason == "" && !options.DisableGFM {
+			continue
+		}
+		if reason == "" {
+			reason = "needs GitHub Flavored Markdown"
+		}
+
+		// Nested tables were done first, and their changes are reported
+		// with them
+		changes := sanitizeTable(table)
+		detail := ""
+		if len(changes) > 0 {
+			detail = " (" + strings.Join(changes, "; ") + ")"
+		}
+		if options.Tables == TABLES_RECORDS {
+			table.ReplaceWithHtml(tableRecords(table))
+			warnings = append(warnings, fmt.Sprintf("Table %d %s: written as a list of records%s", i+1, reason, detail))
+			continue
+		}
+		table.SetAttr(tableHTMLAttr, "")
+		warnings = append(warnings, fmt.Sprintf("Table %d %s: kept as HTML%s", i+1, reason, detail))
+	}
+
+	// In the order of the page
+	for i, j := 0, len(warnings)-1; i < j; i, j = i+1, j-1 {
+		warnings[i], warnings[j] = warnings[j], warnings[i]
+	}
+	return warnings
+}
+
+// tableRows returns the rows of table itself, not of tables nested in it.
+func tableRows(table *goquery.Selection) *goquery.Selection {
+	return table.Find("tr").FilterFunction(func(_ int, row *goquery.Selection) bool {
+		return row.Closest("table").IsSelection(table)
+	})
+}
+
+// tableComplexity says why table can't be a pipe table, or "" if it can.
+func tableComplexity(table *goquery.Selection) string {
+	if table.Find("table").Length() > 0 {
+		return "has a nested table"
+	}
+
+	rows := tableRows(table)
+	cells := rows.Children().Filter("th, td")
+	merged := cells.FilterFunction(func(_ int, cell *goquery.Selection) bool {
+		return cellSpan(cell, "colspan") > 1 || cellSpan(cell, "rowspan") > 1
+	})
+	if merged.Length() > 0 {
+		return "has merged cells"
+	}
+
+	headers := rows.FilterFunction(func(i int, row *goquery.Selection) bool {
+		return isTableHeaderRow(row, i)
+	})
+	if headers.Length() > 1 || (headers.Length() == 1 && !headers.IsSelection(rows.First())) {
+		return "has several header rows"
+	}
+
+	blocks := cells.FilterFunction(func(_ int, cell *goquery.Selection) bool {
+		return cell.Find("ul, ol, dl, pre, blockquote, hr, h1, h2, h3, h4, h5, h6").Length() > 0 ||
+			cell.Find("p").Length() > 1
+	})
+	if blocks.Length() > 0 {
+		return "has lists, code or paragraphs in its cells"
+	}
+	return ""
+}
+
+// isTableHeaderRow reports whether the row at index i of a table holds
+// column headers: it is in <thead>, or it comes first and has only <th>.
+func isTableHeaderRow(row *goquery.Selection, i int) bool {
+	if row.Parent().Is("thead") {
+		return true
+	}
+	cells := row.Children().Filter("th, td")
+	return i == 0 && cells.Length() > 0 && cells.Filter("th").Length() == cells.Length()
+}
+
+func cellSpan(cell *goquery.Selection, attr string) int {
+	span, err := strconv.Atoi(strings.TrimSpace(cell.AttrOr(attr, "1")))
+	if err != nil || span < 1 {
+		return 1
+	}
+	return span
+}
+
+// sanitizeTable strips table down to structure and inline formatting:
+// scripts, forms and embeds are dropped, other unknown elements are
+// replaced by their content, and only attributes such as colspan, href and
+// src are kept. It returns what was lost apart from styling, e.g.
+// "dropped <button>".
+func sanitizeTable(table *goquery.Selection) []string {
+	var dropped, attrs []string
+	unsafeLinks := false
+	note := func(list []string, name string) []string {
+		for _, seen := range list {
+			if seen == name {
+				return list
+			}
+		}
+		return append(list, name)
+	}
+
+	table.Find(tableDroppedSelector).Each(func(_ int, element *goquery.Selection) {
+		dropped = note(dropped, "<"+goquery.NodeName(element)+">")
+	}).Remove()
+
+	table.Find("*").AddSelection(table).Each(func(_ int, element *goquery.Selection) {
+		allowed, known := tableHTMLTags[goquery.NodeName(element)]
+		if !known {
+			if element.Contents().Length() == 0 {
+				element.Remove()
+			} else {
+				element.Contents().Unwrap()
+			}
+			return
+		}
+
+		var names []string
+		for _, attr := range element.Nodes[0].Attr {
+			names = append(names, attr.Key)
+		}
+		for _, name := range names {
+			keep := false
+			for _, allowedName := range allowed {
+				keep = keep || name == allowedName
+			}
+			value := strings.ToLower(strings.TrimSpace(element.AttrOr(name, "")))
+			switch {
+			case !keep:
+				if !tableCosmeticAttrs[name] && !strings.HasPrefix(name, "aria-") && !strings.HasPrefix(name, "data-") {
+					attrs = note(attrs, name)
+				}
+				element.RemoveAttr(name)
+			case strings.HasPrefix(value, "javascript:"):
+				unsafeLinks = true
+				element.RemoveAttr(name)
+			}
+		}
+		if _, hasHref := element.Attr("href"); element.Is("a") && !hasHref {
+			element.Contents().Unwrap()
+		}
+	})
+
+	var changes []string
+	if len(dropped) > 0 {
+		changes = append(changes, "dropped "+strings.Join(dropped, ", "))
+	}
+	if len(attrs) > 0 {
+		changes = append(changes, "removed the "+strings.Join(attrs, ", ")+" attributes")
+	}
+	if unsafeLinks {
+		changes = append(changes, "removed javascript: links")
+	}
+	return changes
+}
+
+// tableGrid lays out the cells of table on a grid, with merged cells in
+// every position they span. It also returns the number of header rows.
+func tableGrid(table *goquery.Selection) ([][]*goquery.Selection, int) {
+	var grid [][]*goquery.Selection
+	headerRows := 0
+
+	tableRows(table).Each(func(i int, row *goquery.Selection) {
+		if headerRows == i && isTableHeaderRow(row, i) {
+			headerRows++
+		}
+		for len(grid) <= i {
+			grid = append(grid, nil)
+		}
+		column := 0
+		row.Children().Filter("th, td").Each(func(_ int, cell *goquery.Selection) {
+			// Skip positions taken by cells from rows above
+			for column < len(grid[i]) && grid[i][column] != nil {
+				column++
+			}
+			for r := i; r < i+cellSpan(cell, "rowspan"); r++ {
+				for len(grid) <= r {
+					grid = append(grid, nil)
+				}
+				for c := column; c < column+cellSpan(cell, "colspan"); c++ {
+					for len(grid[r]) <= c {
+						grid[r] = append(grid[r], nil)
+					}
+					grid[r][c] = cell
+				}
+			}
+			column += cellSpan(cell, "colspan")
+		})
+	})
+	return grid, headerRows
+}
+
+// tableRecords renders table as a list with an item per body row: the
+// first field and, nested below it, the other fields, each labelled with
+// its column headers.
+func tableRecords(table *goquery.Selection) string {
+	grid, headerRows := tableGrid(table)
+
+	columns := 0
+	for _, row := range grid {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	headers := make([][]string, columns)
+	for c := range headers {
+		for r := 0; r < headerRows; r++ {
+			if c >= len(grid[r]) || grid[r][c] == nil {
+				continue
+			}
+			text := strings.Join(strings.Fields(grid[r][c].Text()), " ")
+			if text != "" && (len(headers[c]) == 0 || headers[c][len(headers[c])-1] != text) {
+				headers[c] = append(headers[c], text)
+			}
+		}
+	}
+	var records strings.Builder
+	if caption := strings.TrimSpace(table.ChildrenFiltered("caption").Text()); caption != "" {
+		records.WriteString("<p><strong>" + html.EscapeString(caption) + "</strong></p>")
+	}
+	records.WriteString("<ul>")
+	for _, row := range grid[headerRows:] {
+		var fields []string
+		for c, cell := range row {
+			// A cell spanning columns is one field
+			if cell == nil || (c > 0 && row[c-1] == cell) {
+				continue
+			}
+			last := c
+			for last+1 < len(row) && row[last+1] == cell {
+				last++
+			}
+			content, _ := cell.Html()
+			if strings.TrimSpace(cell.Text()) == "" && cell.Find("img").Length() == 0 {
+				continue
+			}
+			label := html.EscapeString(recordLabel(headers[c:last+1], c))
+			fields = append(fields, "<strong>"+label+":</strong> "+content)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		records.WriteString("<li>" + fields[0])
+		if len(fields) > 1 {
+			records.WriteString("<ul><li>" + strings.Join(fields[1:], "</li><li>") + "</li></ul>")
+		}
+		records.WriteString("</li>")
+	}
+	records.WriteString("</ul>")
+	return records.String()
+}
+
+// recordLabel names the field of a cell spanning columns with the header
+// parts they share, e.g. "Size" for "Size / Width" and "Size / Height".
+func recordLabel(headers [][]string, column int) string {
+	shared := headers[0]
+	for _, parts := range headers[1:] {
+		n := 0
+		for n < len(shared) && n < len(parts) && shared[n] == parts[n] {
+			n++
+		}
+		shared = shared[:n]
+	}
+	if len(shared) == 0 {
+		var all []string
+		for _, parts := range headers {
+			if len(parts) > 0 {
+				all = append(all, strings.Join(parts, " / "))
+			}
+		}
+		if len(all) > 0 {
+			return strings.Join(all, ", ")
+		}
+		// No headers above the cell
+		return fmt.Sprintf("Column %d", column+1)
+	}
+	return strings.Join(shared, " / ")
+}
+
+var (
+	blankLinesPattern = regexp.MustCompile(`\n[ \t]*\n+`)
+	cellBreakPattern  = regexp.MustCompile(`\s*\n+\s*`)
+)
+
+// tableRules write tables as GFM pipe tables, or as HTML if
+// normalizeTables marked them. Unlike the html-to-markdown table plugin
+// they always write a header row and escape pipes in cells.
+func tableRules() []md.Rule {
+	return []md.Rule{
+		{
+			Filter: []string{"table"},
+			Replacement: func(content string, selec *goquery.Selection, _ *md.Options) *string {
+				caption := tableCaption(selec)
+				if _, isHTML := selec.Attr(tableHTMLAttr); isHTML {
+					// Again, for the marker and what the converter's hooks added
+					sanitizeTable(selec)
+					table, _ := goquery.OuterHtml(selec)
+					if caption.Length() > 0 && !caption.Parent().IsSelection(selec) {
+						// Back in its place, first in the table
+						sanitizeTable(caption)
+						captionHTML, _ := goquery.OuterHtml(caption)
+						table = strings.Replace(table, "<table>", "<table>"+captionHTML, 1)
+					}
+					// A blank line would end the HTML block
+					table = "\n\n" + blankLinesPattern.ReplaceAllString(strings.TrimSpace(table), "\n") + "\n\n"
+					return &table
+				}
+				table := pipeTable(content, selec)
+				if text := strings.Join(strings.Fields(caption.Text()), " "); text != "" {
+					// Above the table, like a record list's caption
+					table = "\n\n**" + text + "**" + table
+				}
+				return &table
+			},
+		},
+		{
+			// The table rule writes the caption the GFM plugin moves out of it
+			Filter: []string{"caption"},
+			Replacement: func(_ string, selec *goquery.Selection, _ *md.Options) *string {
+				if !selec.Prev().Is("table") {
+					return nil
+				}
+				empty := ""
+				return &empty
+			},
+		},
+		{
+			Filter: []string{"th", "td"},
+			Replacement: func(content string, _ *goquery.Selection, _ *md.Options) *string {
+				cell := tableCellMark + content
+				return &cell
+			},
+		},
+		{
+			Filter: []string{"tr"},
+			Replacement: func(content string, _ *goquery.Selection, _ *md.Options) *string {
+				row := tableRowMark + content
+				return &row
+			},
+		},
+	}
+}
+
+// tableCaption finds the caption of table, inside it or right after it
+// where the GFM plugin moves it.
+func tableCaption(table *goquery.Selection) *goquery.Selection {
+	if caption := table.ChildrenFiltered("caption"); caption.Length() > 0 {
+		return caption.First()
+	}
+	return table.Next().Filter("caption")
+}
+
+// pipeTable lays out the rows and cells marked in content.
+func pipeTable(content string, table *goquery.Selection) string {
+	var rows [][]string
+	columns := 0
+	for _, row := range strings.Split(content, tableRowMark)[1:] {
+		var cells []string
+		for _, cell := range strings.Split(row, tableCellMark)[1:] {
+			cell = cellBreakPattern.ReplaceAllString(strings.TrimSpace(cell), "<br>")
+			cells = append(cells, escapeTablePipes(cell))
+		}
+		rows = append(rows, cells)
+		if len(cells) > columns {
+			columns = len(cells)
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	// A table without a header row gets an empty one
+	first := tableRows(table).First()
+	header := make([]string, columns)
+	if isTableHeaderRow(first, 0) {
+		copy(header, rows[0])
+		rows = rows[1:]
+	}
+
+	dividers := make([]string, columns)
+	first.Children().Filter("th, td").Each(func(i int, cell *goquery.Selection) {
+		if i >= columns {
+			return
+		}
+		align := strings.ToLower(cell.AttrOr("align", ""))
+		if style := strings.ToLower(cell.AttrOr("style", "")); strings.Contains(style, "text-align") {
+			_, align, _ = strings.Cut(style, "text-align")
+			align = strings.Trim(strings.SplitN(align, ";", 2)[0], " :")
+		}
+		switch align {
+		case "left":
+			dividers[i] = ":--"
+		case "right":
+			dividers[i] = "--:"
+		case "center":
+			dividers[i] = ":-:"
+		}
+	})
+	for i := range dividers {
+		if dividers[i] == "" {
+			dividers[i] = "---"
+		}
+	}
+
+	var text strings.Builder
+	text.WriteString("\n\n")
+	for _, cells := range append([][]string{header, dividers}, rows...) {
+		text.WriteString("|")
+		for c := 0; c < columns; c++ {
+			cell := ""
+			if c < len(cells) {
+				cell = cells[c]
+			}
+			text.WriteString(" " + cell + " |")
+		}
+		text.WriteString("\n")
+	}
+	text.WriteString("\n")
+	return text.String()
+}
+
+// escapeTablePipes escapes the pipes of a cell that aren't escaped yet, in
+// code spans too, as GFM requires.
+func escapeTablePipes(cell string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(cell); i++ {
+		if cell[i] == '|' && (i == 0 || cell[i-1] != '\\') {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteByte(cell[i])
+	}
+	return escaped.String()
+}
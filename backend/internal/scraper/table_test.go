@@ -0,0 +1,162 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// convertTables runs html through the table normalization and the
+// converter, as convertDocument does.
+func convertTables(t *testing.T, html string, options ConverterOptions) (string, []string) {
+	t.Helper()
+	if err := options.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings := normalizeTables(doc, options)
+	normalized, _ := doc.Html()
+	markdown, err := newConverter(options).ConvertString(normalized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(markdown), warnings
+}
+
+func TestTableComplexity(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"simple", `<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table>`, ""},
+		{"thead", `<table><thead><tr><td>A</td></tr></thead><tbody><tr><td>1</td></tr></tbody></table>`, ""},
+		{"one paragraph", `<table><tr><td><p>Text</p></td></tr></table>`, ""},
+		{"nested table", `<table><tr><td><table><tr><td>1</td></tr></table></td></tr></table>`, "has a nested table"},
+		{"colspan", `<table><tr><td colspan="2">1</td></tr></table>`, "has merged cells"},
+		{"rowspan", `<table><tr><td rowspan="2">1</td><td>2</td></tr><tr><td>3</td></tr></table>`, "has merged cells"},
+		{"two header rows", `<table><thead><tr><th>A</th></tr><tr><th>B</th></tr></thead><tr><td>1</td></tr></table>`, "has several header rows"},
+		{"list", `<table><tr><td><ul><li>1</li></ul></td></tr></table>`, "has lists, code or paragraphs in its cells"},
+		{"paragraphs", `<table><tr><td><p>1</p><p>2</p></td></tr></table>`, "has lists, code or paragraphs in its cells"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(test.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tableComplexity(doc.Find("table").First()); got != test.want {
+				t.Errorf("tableComplexity() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTableRecords(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			"merged header",
+			`<table><caption>Sizes</caption>` +
+				`<thead><tr><th rowspan="2">Name</th><th colspan="2">Size</th></tr>` +
+				`<tr><th>Width</th><th>Height</th></tr></thead>` +
+				`<tr><td>Small</td><td>10</td><td>20</td></tr></table>`,
+			"**Sizes**\n\n- **Name:** Small\n  - **Size / Width:** 10\n  - **Size / Height:** 20",
+		},
+		{
+			"cell spanning columns",
+			`<table><tr><th>Name</th><th>Width</th><th>Height</th></tr>` +
+				`<tr><td>Square</td><td colspan="2">10</td></tr></table>`,
+			"- **Name:** Square\n  - **Width, Height:** 10",
+		},
+		{
+			"no headers",
+			`<table><tr><td colspan="2">a</td><td>b</td></tr></table>`,
+			"- **Column 1:** a\n  - **Column 3:** b",
+		},
+		{
+			"empty cells",
+			`<table><tr><th>Name</th><th>Note</th></tr><tr><td>A</td><td> </td></tr><tr><td colspan="2"></td></tr></table>`,
+			"- **Name:** A",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			markdown, warnings := convertTables(t, test.html, ConverterOptions{Tables: TABLES_RECORDS})
+			if markdown != test.want {
+				t.Errorf("Markdown =\n%s\nwant\n%s", markdown, test.want)
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0], "written as a list of records") {
+				t.Errorf("warnings = %q, want one for the records", warnings)
+			}
+		})
+	}
+}
+
+func TestTableCaption(t *testing.T) {
+	const html = `<table><caption>Prices</caption><tr><th>Item</th><th>Price</th></tr><tr><td>Tea</td><td>2</td></tr></table><p>After.</p>`
+
+	markdown, warnings := convertTables(t, html, ConverterOptions{})
+	want := "**Prices**\n\n| Item | Price |\n| --- | --- |\n| Tea | 2 |\n\nAfter."
+	if markdown != want {
+		t.Errorf("pipe table Markdown =\n%s\nwant\n%s", markdown, want)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("pipe table warnings = %q, want none", warnings)
+	}
+
+	merged := strings.Replace(html, "<td>2</td>", `<td rowspan="1" colspan="2">2</td>`, 1)
+	markdown, _ = convertTables(t, merged, ConverterOptions{})
+	if !strings.HasPrefix(markdown, "<table><caption>Prices</caption>") {
+		t.Errorf("HTML table lost its caption:\n%s", markdown)
+	}
+	if strings.Count(markdown, "Prices") != 1 {
+		t.Errorf("caption written more than once:\n%s", markdown)
+	}
+}
+
+func TestTableHTMLWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			"styling only",
+			`<table class="wide" style="color: red"><tr><td colspan="2" data-id="1">1</td></tr></table>`,
+			"Table 1 has merged cells: kept as HTML",
+		},
+		{
+			"dropped content",
+			`<table><tr><td colspan="2">1 <button>Copy</button><svg></svg><a href="javascript:go()" title="Go">go</a></td>` +
+				`<td onclick="x()" headers="a">2</td></tr></table>`,
+			"Table 1 has merged cells: kept as HTML (dropped <button>, <svg>; removed the onclick, headers attributes; removed javascript: links)",
+		},
+		{
+			"nested table",
+			`<table><tr><td><table><tr><td>1</td></tr></table></td></tr></table>`,
+			"Table 1 has a nested table: kept as HTML",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			markdown, warnings := convertTables(t, test.html, ConverterOptions{})
+			if len(warnings) != 1 || warnings[0] != test.want {
+				t.Errorf("warnings = %q, want %q", warnings, test.want)
+			}
+			if strings.Contains(markdown, "onclick") || strings.Contains(markdown, "javascript:") {
+				t.Errorf("unsafe attributes kept:\n%s", markdown)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the LLM tokens of a text. Plug in a real BPE tokenizer
+// for exact counts; ApproximateTokenizer works offline without one.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc adapts a function to a Tokenizer.
+type TokenizerFunc func(text string) int
+
+func (f TokenizerFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// ApproximateTokenizer estimates the count of GPT-style BPE tokenizers
+// (cl100k_base and the like) without their vocabulary. It splits text the
+// way they do before merging, into words, numbers, punctuation and
+// whitespace, and guesses how many tokens each piece becomes. It is the
+// default.
+var ApproximateTokenizer Tokenizer = TokenizerFunc(approximateTokens)
+
+// TextSize is the size of a text in characters, words and tokens.
+type TextSize struct {
+	Chars  int `json:"chars"`
+	Words  int `json:"words"`
+	Tokens int `json:"tokens"`
+}
+
+// MeasureText counts text with tokenizer, or ApproximateTokenizer if it is
+// nil.
+func MeasureText(text string, tokenizer Tokenizer) TextSize {
+	if tokenizer == nil {
+		tokenizer = ApproximateTokenizer
+	}
+	return TextSize{
+		Chars:  utf8.RuneCountInString(text),
+		Words:  len(strings.Fields(text)),
+		Tokens: tokenizer.CountTokens(text),
+	}
+}
+
+// Add returns the sum of two sizes.
+func (t TextSize) Add(other TextSize) TextSize {
+	return TextSize{
+		Chars:  t.Chars + other.Chars,
+		Words:  t.Words + other.Words,
+		Tokens: t.Tokens + other.Tokens,
+	}
+}
+
+func approximateTokens(text string) int {
+	runes := []rune(text)
+	tokens := 0
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		j := i + 1
+		switch {
+		case isIdeograph(r):
+			// CJK text is mostly a token per character
+			tokens++
+
+		case unicode.IsLetter(r):
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsMark(runes[j])) && !isIdeograph(runes[j]) {
+				j++
+			}
+			tokens += wordTokens(j - i)
+
+		case unicode.IsDigit(r):
+			// Numbers are split into groups of up to three digits
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			tokens += (j - i + 2) / 3
+
+		case r == '\n' || r == '\r':
+			// Line breaks and the indentation after them merge into one
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+			tokens++
+
+		case unicode.IsSpace(r):
+			for j < len(runes) && unicode.IsSpace(runes[j]) && runes[j] != '\n' && runes[j] != '\r' {
+				j++
+			}
+			// A single space is part of the word or punctuation after it
+			if j-i > 1 || j == len(runes) || !joinsSpace(runes[j]) {
+				tokens++
+			}
+
+		default:
+			// Runs of punctuation and symbols merge in pairs on average
+			for j < len(runes) && isSymbol(runes[j]) {
+				j++
+			}
+			tokens += (j - i + 1) / 2
+		}
+		i = j
+	}
+	return tokens
+}
+
+// wordTokens guesses the tokens of a word of n letters: common words up to
+// about six letters are in the vocabulary whole, longer ones break into
+// pieces of about four letters.
+func wordTokens(n int) int {
+	if n <= 6 {
+		return 1
+	}
+	return 1 + (n-6+3)/4
+}
+
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// joinsSpace reports whether a space before r is merged into r's token.
+func joinsSpace(r rune) bool {
+	return !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestApproximateTokens(t *testing.T) {
+	tests := []struct {
+		text   string
+		tokens int
+	}{
+		{"", 0},
+		{"Hello world", 2},
+		{"hello, world!", 4},
+		{"internationalization", 5},
+		{"1234567", 3},
+		{"## Setup\n\n    go build", 5},
+		{"日本語", 3},
+	}
+	for _, test := range tests {
+		if got := ApproximateTokenizer.CountTokens(test.text); got != test.tokens {
+			t.Errorf("CountTokens(%q) = %d, want %d", test.text, got, test.tokens)
+		}
+	}
+}
+
+func TestMeasureText(t *testing.T) {
+	size := MeasureText("Grüße aus  Köln\n", TokenizerFunc(func(string) int { return 42 }))
+	if size != (TextSize{Chars: 16, Words: 3, Tokens: 42}) {
+		t.Errorf("MeasureText() = %+v, want chars as runes, words and the plugged-in count", size)
+	}
+	if total := size.Add(TextSize{Chars: 1, Words: 1, Tokens: 1}); total != (TextSize{Chars: 17, Words: 4, Tokens: 43}) {
+		t.Errorf("Add() = %+v", total)
+	}
+}
+
+func TestMaxTokensStopsTheCrawl(t *testing.T) {
+	site := linkedSite(map[string][]string{
+		"/":  {"/a"},
+		"/a": {"/b"},
+		"/b": {"/c"},
+		"/c": nil,
+	})
+	config := &ScrapingConfig{
+		MaxDepth:    5,
+		Concurrency: 1,
+		Fetcher:     site,
+		Log:         io.Discard,
+		MaxTokens:   150,
+		Tokenizer:   TokenizerFunc(func(string) int { return 100 }),
+	}
+
+	sink := &collectSink{}
+	if err := NewScraper(config).ScrapeTo(context.Background(), sink, "https://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.pages) != 2 {
+		t.Fatalf("crawl wrote %v, want it to stop after the page that spent the budget", sink.urls())
+	}
+	if sink.pages[0].Tokens != 100 || sink.pages[0].Words == 0 {
+		t.Errorf("page size = %+v, want it measured with the configured tokenizer", sink.pages[0].TextSize)
+	}
+}
@@ -0,0 +1,382 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	WARC_VERSION = "WARC/1.1"
+
+	MAX_WARC_RECORD_SIZE  = 64 << 20 // bytes in one record's block
+	MAX_WARC_ARCHIVE_SIZE = 1 << 30  // bytes of responses LoadWARC keeps in memory
+)
+
+// ErrWARCRecordTooLarge is returned by WARCReader.Next for a record whose
+// block is over MAX_WARC_RECORD_SIZE. The block is skipped, so reading can
+// go on with the next record.
+var ErrWARCRecordTooLarge = errors.New("WARC record too large")
+
+// WARCWriter appends records to a WARC file. It is safe for concurrent use.
+type WARCWriter struct {
+	w        io.Writer
+	closer   io.Closer
+	compress bool // gzip each record separately, as .warc.gz readers expect
+	mutex    sync.Mutex
+	err      error
+}
+
+// CreateWARC creates a WARC file at path, gzip-compressed if path ends in
+// .gz, and writes its warcinfo record.
+func CreateWARC(path string) (*WARCWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := NewWARCWriter(file, strings.HasSuffix(path, ".gz"))
+	w.closer = file
+
+	info := "software: Website-Markdown-Converter/1.0\r\nformat: WARC File Format 1.1\r\n"
+	if _, err := w.writeRecord("warcinfo", "", "application/warc-fields", []byte(info)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func NewWARCWriter(w io.Writer, compress bool) *WARCWriter {
+	return &WARCWriter{w: w, compress: compress}
+}
+
+// WriteExchange records one HTTP request and its response as a pair of
+// linked request and response records.
+func (w *WARCWriter) WriteExchange(targetURI string, request, response []byte) error {
+	responseID, err := w.writeRecord("response", targetURI, "application/http;msgtype=response", response)
+	if err != nil {
+		return err
+	}
+	_, err = w.writeRecord("request", targetURI, "application/http;msgtype=request", request,
+		[2]string{"WARC-Concurrent-To", responseID})
+	return err
+}
+
+func (w *WARCWriter) writeRecord(recordType, targetURI, contentType string, block []byte, extra ...[2]string) (string, error) {
+	id := "<urn:uuid:" + newUUID() + ">"
+
+	var record bytes.Buffer
+	record.WriteString(WARC_VERSION + "\r\n")
+	record.WriteString("WARC-Type: " + recordType + "\r\n")
+	record.WriteString("WARC-Record-ID: " + id + "\r\n")
+	record.WriteString("WARC-Date: " + time.Now().UTC().Format(time.RFC3339) + "\r\n")
+	if targetURI != "" {
+		record.WriteString("WARC-Target-URI: " + targetURI + "\r\n")
+	}
+	for _, header := range extra {
+		record.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	record.WriteString("Content-Type: " + contentType + "\r\n")
+	record.WriteString("Content-Length: " + strconv.Itoa(len(block)) + "\r\n\r\n")
+	record.Write(block)
+	record.WriteString("\r\n\r\n")
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err != nil {
+		return "", w.err
+	}
+
+	if w.compress {
+		gz := gzip.NewWriter(w.w)
+		if _, w.err = gz.Write(record.Bytes()); w.err == nil {
+			w.err = gz.Close()
+		}
+	} else {
+		_, w.err = w.w.Write(record.Bytes())
+	}
+	return id, w.err
+}
+
+// Close closes the underlying file and reports the first write error.
+func (w *WARCWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closer != nil {
+		if err := w.closer.Close(); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+	return w.err
+}
+
+// warcTransport records every request and response that passes through it,
+// as sent over the wire: HTTPFetcher asks for gzip itself, so the transport
+// below leaves the body compressed and its headers as they were.
+type warcTransport struct {
+	base http.RoundTripper
+	warc *WARCWriter
+}
+
+func (t *warcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := httputil.DumpRequestOut(req, false)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	// Reads the body and puts an in-memory copy back for the caller
+	response, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if err := t.warc.WriteExchange(req.URL.String(), request, response); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to record %s to the WARC file: %v", req.URL, err)
+	}
+	return resp, nil
+}
+
+// RecordTo makes f write every request and response, including redirects,
+// to warc.
+func (f *HTTPFetcher) RecordTo(warc *WARCWriter) {
+	base := f.Client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	f.Client.Transport = &warcTransport{base: base, warc: warc}
+}
+
+// WARCRecord is one record of a WARC file.
+type WARCRecord struct {
+	Header textproto.MIMEHeader
+	Block  []byte
+}
+
+func (r *WARCRecord) Type() string {
+	return r.Header.Get("WARC-Type")
+}
+
+func (r *WARCRecord) TargetURI() string {
+	// Some writers wrap the URI in angle brackets
+	return strings.Trim(r.Header.Get("WARC-Target-URI"), "<>")
+}
+
+// WARCReader reads records from a WARC file, plain or gzip-compressed.
+type WARCReader struct {
+	r             *bufio.Reader
+	maxRecordSize int64
+}
+
+func NewWARCReader(r io.Reader) (*WARCReader, error) {
+	buffered := bufio.NewReader(r)
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		buffered = bufio.NewReader(gz)
+	}
+	return &WARCReader{r: buffered, maxRecordSize: MAX_WARC_RECORD_SIZE}, nil
+}
+
+// Next returns the next record, or io.EOF after the last one.
+func (r *WARCReader) Next() (*WARCRecord, error) {
+	// Skip the blank lines that end the previous record
+	var version string
+	for version == "" {
+		line, err := r.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) == "" {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read WARC record: %v", err)
+		}
+		version = strings.TrimSpace(line)
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, fmt.Errorf("not a WARC record: %q", version)
+	}
+
+	header, err := textproto.NewReader(r.r).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WARC headers: %v", err)
+	}
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid WARC Content-Length %q", header.Get("Content-Length"))
+	}
+
+	if length > r.maxRecordSize {
+		if _, err := io.CopyN(io.Discard, r.r, length); err != nil {
+			return nil, fmt.Errorf("failed to read WARC block: %v", err)
+		}
+		return nil, fmt.Errorf("%w: %s has %d bytes, the maximum is %d", ErrWARCRecordTooLarge, header.Get("WARC-Record-ID"), length, r.maxRecordSize)
+	}
+
+	// The length is untrusted: the buffer only grows as the data arrives
+	var block bytes.Buffer
+	if _, err := io.CopyN(&block, r.r, length); err != nil {
+		return nil, fmt.Errorf("failed to read WARC block: %v", err)
+	}
+
+	return &WARCRecord{Header: header, Block: block.Bytes()}, nil
+}
+
+// WARCFetcher replays HTTP responses from a WARC archive instead of going to
+// the network. URLs that are not in the archive are reported as 404.
+type WARCFetcher struct {
+	responses map[string][]byte // target URI -> raw HTTP response
+	pages     []string          // archived HTML pages with status 200, in archive order
+}
+
+// LoadWARC reads every response record of a WARC archive into memory. It
+// fails once the responses add up to more than MAX_WARC_ARCHIVE_SIZE, and
+// skips records over MAX_WARC_RECORD_SIZE.
+func LoadWARC(r io.Reader) (*WARCFetcher, error) {
+	return loadWARC(r, MAX_WARC_ARCHIVE_SIZE)
+}
+
+func loadWARC(r io.Reader, maxSize int64) (*WARCFetcher, error) {
+	reader, err := NewWARCReader(r)
+	if err != nil {
+		return nil, err
+	}
+	reader.maxRecordSize = min(reader.maxRecordSize, maxSize)
+
+	f := &WARCFetcher{responses: make(map[string][]byte)}
+	var size int64
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, ErrWARCRecordTooLarge) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if record.Type() != "response" || !strings.HasPrefix(record.Header.Get("Content-Type"), "application/http") {
+			continue
+		}
+
+		// The first capture of a URL wins
+		uri := record.TargetURI()
+		if _, seen := f.responses[uri]; seen {
+			continue
+		}
+		if size += int64(len(record.Block)); size > maxSize {
+			return nil, fmt.Errorf("the archived responses take more than %d bytes: split the archive", maxSize)
+		}
+		f.responses[uri] = record.Block
+
+		resp, err := parseWARCResponse(record.Block, uri)
+		if err == nil && resp.StatusCode == 200 && strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+			f.pages = append(f.pages, uri)
+		}
+	}
+	return f, nil
+}
+
+// Pages returns the archived HTML pages, in archive order.
+func (f *WARCFetcher) Pages() []string {
+	return f.pages
+}
+
+func (f *WARCFetcher) Fetch(ctx context.Context, rawURL string) (*FetchResponse, error) {
+	block, exists := f.responses[rawURL]
+	if !exists {
+		return notFoundResponse(rawURL), nil
+	}
+	return parseWARCResponse(block, rawURL)
+}
+
+// ScrapeWARC converts the HTML pages archived in a WARC file by replaying
+// them through the normal pipeline, without going to the network. Only
+// archived pages are converted (links are not followed), grouped by site.
+//
+// The archive replaces the configured Fetcher and no Delay is applied.
+func (s *Scraper) ScrapeWARC(ctx context.Context, r io.Reader, sink PageSink) error {
+	fetcher, err := LoadWARC(r)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to read WARC archive: %v", err)
+	}
+	if len(fetcher.Pages()) == 0 {
+		return fmt.Errorf("🚫 No HTML pages found in WARC archive")
+	}
+
+	s.fetcher = fetcher
+	s.polite = newPoliteness(s.config.Concurrency, 0)
+	s.config.MaxDepth = 0
+
+	var targets []crawlTarget
+	for _, pageURL := range fetcher.Pages() {
+		parsedURL, err := url.Parse(pageURL)
+		if err != nil {
+			continue
+		}
+		seed := parsedURL.Scheme + "://" + parsedURL.Host + "/"
+		s.addSeed(seed, parsedURL.Host)
+		targets = append(targets, crawlTarget{URL: pageURL, Seed: seed})
+	}
+
+	s.logf("🗄️  Replaying %d archived pages\n", len(targets))
+	return s.crawl(ctx, targets, sink)
+}
+
+// parseWARCResponse parses a recorded HTTP response, undoing chunked and
+// gzip encodings that were captured on the wire.
+func parseWARCResponse(block []byte, rawURL string) (*FetchResponse, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archived response: %v", err)
+	}
+
+	body, err := decodeBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("invalid archived response: %v", err)
+	}
+
+	return &FetchResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		URL:        rawURL,
+	}, nil
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,189 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const warcTestPage = "<html><head><title>Archived</title></head><body><p>Hello from the archive.</p></body></html>"
+
+func TestWARCRoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			io.WriteString(w, warcTestPage)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		io.WriteString(gz, warcTestPage)
+		gz.Close()
+	}))
+	defer server.Close()
+
+	for _, compress := range []bool{false, true} {
+		var archive bytes.Buffer
+		warc := NewWARCWriter(&archive, compress)
+		fetcher := NewHTTPFetcher("test")
+		fetcher.RecordTo(warc)
+
+		resp, err := fetcher.Fetch(context.Background(), server.URL+"/old")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != warcTestPage || resp.Header.Get("Content-Encoding") != "" {
+			t.Fatalf("Fetch() body = %q, Content-Encoding %q, want the decoded page", body, resp.Header.Get("Content-Encoding"))
+		}
+		if err := warc.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The redirect and the page, each as a response and a request
+		reader, err := NewWARCReader(bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var types []string
+		var recorded []byte
+		for {
+			record, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			types = append(types, record.Type())
+			if record.Type() == "response" && record.TargetURI() == server.URL+"/page" {
+				recorded = record.Block
+			}
+		}
+		if got := strings.Join(types, ","); got != "response,request,response,request" {
+			t.Errorf("compress=%t: record types = %s", compress, got)
+		}
+		// The response is kept as it was sent: compressed, with its headers
+		if !bytes.Contains(recorded, []byte("Content-Encoding: gzip")) || !bytes.Contains(recorded, []byte("\r\n\r\n\x1f\x8b")) {
+			t.Errorf("compress=%t: recorded response isn't the one on the wire:\n%q", compress, recorded)
+		}
+
+		replay, err := LoadWARC(bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pages := replay.Pages(); len(pages) != 1 || pages[0] != server.URL+"/page" {
+			t.Errorf("compress=%t: Pages() = %v", compress, pages)
+		}
+		resp, err = replay.Fetch(context.Background(), server.URL+"/page")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != warcTestPage {
+			t.Errorf("compress=%t: replayed body = %q", compress, body)
+		}
+		if resp, _ := replay.Fetch(context.Background(), server.URL+"/missing"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("compress=%t: missing URL status = %d, want 404", compress, resp.StatusCode)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrShortWrite
+}
+
+func TestWARCWriteErrorFailsFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, warcTestPage)
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher("test")
+	fetcher.RecordTo(NewWARCWriter(failingWriter{}, false))
+	if _, err := fetcher.Fetch(context.Background(), server.URL); err == nil {
+		t.Error("Fetch() succeeded although the exchange couldn't be recorded")
+	}
+}
+
+func archivedPages(t *testing.T, pages ...string) []byte {
+	t.Helper()
+	var archive bytes.Buffer
+	warc := NewWARCWriter(&archive, false)
+	for _, page := range pages {
+		response := "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n" + page
+		if err := warc.WriteExchange("https://example.com/"+strconv.Itoa(len(page)), []byte("GET / HTTP/1.1\r\n\r\n"), []byte(response)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := warc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive.Bytes()
+}
+
+func TestWARCReaderLimitsRecords(t *testing.T) {
+	reader, err := NewWARCReader(bytes.NewReader(archivedPages(t, strings.Repeat("x", 500), "small")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.maxRecordSize = 200
+
+	// The big page's response is skipped, the records around it are read
+	var types []string
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, ErrWARCRecordTooLarge) {
+			types = append(types, "too large")
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, record.Type())
+	}
+	if got := strings.Join(types, ", "); got != "too large, request, response, request" {
+		t.Errorf("records = %s, want the big response skipped", got)
+	}
+
+	// A length the data doesn't back up is an error, not an allocation
+	lying := "WARC/1.1\r\nWARC-Type: response\r\nContent-Length: 60000000\r\n\r\nshort"
+	reader, err = NewWARCReader(strings.NewReader(lying))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Next(); err == nil {
+		t.Error("Next() accepted a record shorter than its Content-Length")
+	}
+}
+
+func TestLoadWARCLimitsTheArchive(t *testing.T) {
+	archive := archivedPages(t, warcTestPage, warcTestPage+" ")
+	if _, err := loadWARC(bytes.NewReader(archive), 1<<20); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadWARC(bytes.NewReader(archive), int64(len(warcTestPage))+100); err == nil {
+		t.Error("loadWARC() kept more responses than the limit")
+	}
+}
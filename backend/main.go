@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"website-markdown/cmd"
 	"website-markdown/internal/api"
@@ -11,8 +12,22 @@ import (
 )
 
 var (
-	serverMode bool
-	port       string
+	serverMode   bool
+	configFile   string
+	port         string
+	bindAddress  string
+	corsOrigins  []string
+	publicURL    string
+	tlsCert      string
+	tlsKey       string
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	maxBodyBytes int64
+	drainTimeout time.Duration
+	storePath    string
+	jobTTL       time.Duration
+	apiKeysFile  string
+	allowHosts   []string
 )
 
 var rootCmd = &cobra.Command{
@@ -30,11 +45,18 @@ Examples:
   website-markdown https://example.com --depth 2 --output ./docs
 
   # Server mode  
-  website-markdown --server --port 8080`,
+  website-markdown --server --port 8080
+  website-markdown --server --api-keys ./keys.json
+  website-markdown --server --config ./server.yaml
+  website-markdown --server --bind 127.0.0.1 --cors-origins https://docs.example.com`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if serverMode {
 			fmt.Println("🚀 Starting in server mode...")
-			return api.StartAPIServer(port)
+			config, err := loadServerConfig(cmd)
+			if err != nil {
+				return err
+			}
+			return api.StartAPIServer(config)
 		}
 
 		// If no URL provided and not in server mode, show help
@@ -48,16 +70,85 @@ Examples:
 }
 
 func init() {
-	rootCmd.Flags().BoolVar(&serverMode, "server", false, "Run as API server")
-	rootCmd.Flags().StringVar(&port, "port", "8080", "API server port (only used with --server)")
+	rootCmd.Flags().BoolVarP(&serverMode, "server", "s", false, "Run as API server")
+	rootCmd.Flags().StringVar(&configFile, "config", "", "Server config file, JSON or YAML (only used with --server)")
+	rootCmd.Flags().StringVar(&port, "port", api.DEFAULT_PORT, "API server port (only used with --server)")
+	rootCmd.Flags().StringVar(&bindAddress, "bind", "", "Address to bind, e.g. 127.0.0.1 (default: all interfaces)")
+	rootCmd.Flags().StringSliceVar(&corsOrigins, "cors-origins", api.DefaultAllowedOrigins, "Allowed CORS origins")
+	rootCmd.Flags().StringVar(&publicURL, "public-url", "", "Public URL of the server, used in webhook result links")
+	rootCmd.Flags().StringVar(&tlsCert, "tls-cert", "", "TLS certificate file (enables HTTPS with --tls-key)")
+	rootCmd.Flags().StringVar(&tlsKey, "tls-key", "", "TLS private key file")
+	rootCmd.Flags().DurationVar(&readTimeout, "read-timeout", api.DEFAULT_READ_TIMEOUT, "HTTP read timeout")
+	rootCmd.Flags().DurationVar(&writeTimeout, "write-timeout", 0, "HTTP write timeout (0 disables it)")
+	rootCmd.Flags().Int64Var(&maxBodyBytes, "max-body-bytes", api.DEFAULT_MAX_BODY_BYTES, "Maximum request body size in bytes")
+	rootCmd.Flags().DurationVar(&drainTimeout, "drain-timeout", api.DEFAULT_DRAIN_TIMEOUT, "How long running crawls may finish on shutdown before being cancelled")
+	rootCmd.Flags().StringVar(&storePath, "store", "", "BoltDB file for persisting jobs and results (default: in memory)")
+	rootCmd.Flags().DurationVar(&jobTTL, "job-ttl", api.DEFAULT_JOB_TTL, "How long finished jobs are kept (0 keeps them forever)")
+	rootCmd.Flags().StringVar(&apiKeysFile, "api-keys", "", "JSON file with API keys and quotas (only used with --server)")
+	rootCmd.Flags().StringSliceVar(&allowHosts, "allow-hosts", nil, "Hosts, IPs or CIDRs exempt from the private network block (only used with --server)")
+}
+
+// loadServerConfig layers explicitly set flags over the config file and
+// environment variables.
+func loadServerConfig(cmd *cobra.Command) (*api.ServerConfig, error) {
+	config, err := api.LoadServerConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("port") {
+		config.Port = port
+	}
+	if flags.Changed("bind") {
+		config.BindAddress = bindAddress
+	}
+	if flags.Changed("cors-origins") {
+		config.AllowedOrigins = corsOrigins
+	}
+	if flags.Changed("public-url") {
+		config.PublicURL = publicURL
+	}
+	if flags.Changed("tls-cert") {
+		config.TLSCertFile = tlsCert
+	}
+	if flags.Changed("tls-key") {
+		config.TLSKeyFile = tlsKey
+	}
+	if flags.Changed("read-timeout") {
+		config.ReadTimeout = api.Duration(readTimeout)
+	}
+	if flags.Changed("write-timeout") {
+		config.WriteTimeout = api.Duration(writeTimeout)
+	}
+	if flags.Changed("max-body-bytes") {
+		config.MaxBodyBytes = maxBodyBytes
+	}
+	if flags.Changed("drain-timeout") {
+		config.DrainTimeout = api.Duration(drainTimeout)
+	}
+	if flags.Changed("store") {
+		config.StorePath = storePath
+	}
+	if flags.Changed("job-ttl") {
+		config.JobTTL = api.Duration(jobTTL)
+	}
+	if flags.Changed("api-keys") {
+		config.APIKeysFile = apiKeysFile
+	}
+	if flags.Changed("allow-hosts") {
+		config.AllowHosts = append(config.AllowHosts, allowHosts...)
+	}
+
+	return config, nil
 }
 
 func main() {
 	if len(os.Args) > 1 {
 		// Check if running in server mode
 		if os.Args[1] == "--server" || os.Args[1] == "-s" {
-			fmt.Println("🚀 Starting API server...")
-			if err := api.StartAPIServer(port); err != nil {
+			// Parse server flags (--port, --api-keys, ...)
+			if err := rootCmd.Execute(); err != nil {
 				fmt.Printf("❌ Server failed to start: %v\n", err)
 				os.Exit(1)
 			}
@@ -73,7 +164,7 @@ func main() {
 
 	// Default to CLI mode
 	if err := cmd.Execute(); err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
 		os.Exit(1)
 	}
 }